@@ -3,61 +3,195 @@ package main
 import (
 	"context"
 	_ "embed"
+	"errors"
 	"flag"
 	"fmt"
+	"gographics/dashboard"
 	"gographics/game"
 	"gographics/neapy"
 	_ "image/png"
 	"log"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
+	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
-	hook "github.com/robotn/gohook"
 	"github.com/yaricom/goNEAT/v4/experiment"
 	"github.com/yaricom/goNEAT/v4/neat"
 	"github.com/yaricom/goNEAT/v4/neat/genetics"
 )
 
-const (
-	windowWidth  = 640
-	windowHeight = 480
+var (
+	mode       = flag.String("mode", "train", "run mode: train, human, race, watch or eval")
+	racer      = flag.String("racer", "neat", "opponent in race mode: neat, scripted or random")
+	genomeFile = flag.String("genome", "", "genome YAML or network JSON file to play with, defaults to the champion in the output directory")
+	seed       = flag.Int64("seed", 1, "course seed, 0 for a random course every game")
+	episodes   = flag.Int("episodes", 10, "number of courses to evaluate in eval mode, seeded from -seed on")
+	ghostFiles = flag.String("ghosts", "", "genome or network files replayed as ghosts on the -seed course in watch and race modes, separated by commas")
+	maxSteps   = flag.Int("max-steps", 100000, "steps after which an eval episode is stopped")
+
+	width      = flag.Int("width", game.WorldWidth, "window width the 640x480 picture is scaled to, 0 with -height 0 follows the window")
+	height     = flag.Int("height", game.WorldHeight, "window height the 640x480 picture is scaled to")
+	fullscreen = flag.Bool("fullscreen", false, "start in fullscreen, F11 toggles it")
+
+	shaders     = flag.String("shaders", "grading,scanlines,vignette,crt", "post-processing shader chain, C toggles it; uniforms like vignette:Strength=0.8")
+	postProcess = flag.Bool("post", false, "start with the post-processing shaders enabled")
+	speed       = flag.Float64("speed", 1, "simulation speed from 0.25x to 64x, 1x is the normal pace, - and = halve and double it; not in human and race modes")
+	paused      = flag.Bool("paused", false, "start paused, P pauses and resumes, . makes a single step")
+	detach      = flag.Bool("detach", true, "train at full speed on a simulation goroutine, the window only shows its latest state; false steps on the window ticks")
+	renderEvery = flag.Int("render-every", 1, "render only every Nth generation and fast-forward the others, G cycles it")
+	record      = flag.Bool("record", false, "record the window from the start, R starts and stops recording any time")
+	recordDir   = flag.String("record-dir", "./out/recordings", "directory recordings are written into")
+	recordGIF   = flag.Bool("record-gif", true, "record an animated GIF of at most 600 frames, a sequence of PNG frames otherwise")
+	recordSkip  = flag.Int("record-skip", 1, "frames skipped after every recorded one")
+	recordScale = flag.Float64("record-scale", 0.5, "size of recorded frames relative to the window")
+	hud         = flag.String("hud", "alive,generation,fitness,pipes,species,speed,sparkline", "training HUD items in order, empty hides the HUD")
+
+	configFile      = flag.String("config", "./data/flappy.eval.yaml", "flappy evaluator config file")
+	startGenomeFile = flag.String("start-genome", "", "start genome for training, generated from the sensor manifest in -config if empty")
+	outDir          = flag.String("out", "./out", "output directory for checkpoints and champions")
+	checkpointEvery = flag.Int("checkpoint-every", 10, "generations between training checkpoints, 0 disables checkpoints")
+	dashboardAddr   = flag.String("dashboard", "", "address to serve the training dashboard on, like localhost:8080, disabled if empty")
+	resume          = flag.Bool("resume", false, "resume training from the latest checkpoint in the output directory")
 )
 
 func main() {
-	ebiten.SetWindowSize(windowWidth, windowHeight)
+	flag.Parse()
+	var evalConfig *neapy.Config
+	switch *mode {
+	case "eval":
+		if *episodes < 1 {
+			log.Fatalf("Invalid episodes: %d, at least one course is needed", *episodes)
+		}
+		runEval(loadChampion())
+		return
+	case "train":
+		cfg, err := neapy.ReadConfigFromFile(*configFile)
+		if err != nil {
+			log.Fatal("Failed to load evaluator config: ", err)
+		}
+		cfg.OutDir = *outDir
+		if cfg.Parallel {
+			// headless training, there is nothing to show
+			runExperiment(nil, cfg)
+			return
+		}
+		evalConfig = cfg
+	}
+	windowW, windowH := *width, *height
+	if windowW == 0 || windowH == 0 {
+		windowW, windowH = game.WorldWidth, game.WorldHeight
+	}
+	ebiten.SetWindowSize(windowW, windowH)
+	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
+	ebiten.SetFullscreen(*fullscreen)
 	ebiten.SetWindowTitle("Flappy Gopher")
 	ebiten.SetTPS(60)
-	game := game.NewGame(windowWidth, windowHeight, 20)
 
-	go runExperiment(game)
-	if err := ebiten.RunGame(game); err != nil {
+	// simulation stops the detached simulation once training is over or the window is closed
+	simulation, stopSimulation := context.WithCancel(context.Background())
+	defer stopSimulation()
+	var g *game.Game
+	switch *mode {
+	case "train":
+		g = game.NewGame(game.WorldWidth, game.WorldHeight, 20)
+		go func() {
+			runExperiment(g, evalConfig)
+			stopSimulation()
+		}()
+	case "human":
+		g = game.NewHumanGame(game.WorldWidth, game.WorldHeight)
+	case "race":
+		g = game.NewRaceGame(game.WorldWidth, game.WorldHeight, *seed, newRacer())
+	case "watch":
+		g = game.NewGame(game.WorldWidth, game.WorldHeight, 1)
+		go runWatch(g, loadChampion())
+	default:
+		log.Fatalf("Unknown mode: %s", *mode)
+	}
+	if err := g.SetResolution(*width, *height); err != nil {
+		log.Fatal("Invalid resolution: ", err)
+	}
+	if *ghostFiles != "" && (*mode == "watch" || *mode == "race") {
+		g.SetGhosts(loadGhosts())
+	}
+	chain, err := game.ParseShaderChain(*shaders)
+	if err != nil {
+		log.Fatal("Invalid shader chain: ", err)
+	}
+	pp, err := game.NewPostProcess(chain, *postProcess)
+	if err != nil {
+		log.Fatal("Failed to create post-processing: ", err)
+	}
+	g.SetPostProcess(pp)
+	hudItems, err := game.ParseHUD(*hud)
+	if err != nil {
+		log.Fatal("Invalid HUD: ", err)
+	}
+	g.SetHUD(hudItems)
+	if *mode == "human" || *mode == "race" {
+		// the player sets the pace
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "speed" || f.Name == "paused" || f.Name == "render-every" {
+				log.Fatalf("-%s does not apply to %s mode", f.Name, *mode)
+			}
+		})
+	}
+	if *mode == "train" && *detach {
+		// the detached simulation runs at full speed
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "speed" || f.Name == "render-every" {
+				log.Fatalf("-%s needs -detach=false in train mode", f.Name)
+			}
+		})
+	}
+	if err = g.SetSpeed(*speed); err != nil {
+		log.Fatal("Invalid speed: ", err)
+	}
+	if err = g.SetRenderEvery(*renderEvery); err != nil {
+		log.Fatal("Invalid render every: ", err)
+	}
+	g.SetPaused(*paused)
+	if *detach && *mode == "train" {
+		g.Detach(simulation)
+	}
+	g.SetCaptureOptions(game.CaptureOptions{Dir: *recordDir, GIF: *recordGIF, Skip: *recordSkip, Scale: *recordScale})
+	if *record {
+		if err := g.StartRecording(); err != nil {
+			log.Fatal("Failed to start recording: ", err)
+		}
+	}
+	if err := ebiten.RunGame(g); err != nil {
 		panic(err)
 	}
+	stopSimulation()
+	if err := g.StopRecording(); err != nil {
+		log.Fatal(err)
+	}
 
 	// time.Sleep(time.Second * 10)
 }
 
-func humanPlayer(game *game.Game) {
-	hook.Register(hook.MouseHold, []string{}, func(e hook.Event) {
-		go func() {
-			_ = game.SyncInput(map[int]bool{1: true, 2: true})
-		}()
-	})
-	go func() {
-		s := hook.Start()
-		fmt.Println("waiting clicks")
-		<-hook.Process(s)
-		fmt.Println("done hook")
-	}()
+func newRacer() game.Controller {
+	switch *racer {
+	case "neat":
+		return loadChampion()
+	case "scripted":
+		return game.ScriptedController{}
+	case "random":
+		return game.NewRandomController(*seed, 0.05)
+	default:
+		log.Fatalf("Unknown racer: %s", *racer)
+	}
+	return nil
 }
 
-func runExperiment(g *game.Game) {
+// runExperiment trains on the game window or, if g is nil, on parallel headless games.
+func runExperiment(g *game.Game, evalConfig *neapy.Config) {
 	contextPath := "./data/flappy.neat.yaml"
-	genomePath := "./data/flappy_start.yaml"
 	experimentName := "Flappy"
-	flag.Parse()
 
 	// Load NEAT options
 	neatOptions, err := neat.ReadNeatOptionsFromFile(contextPath)
@@ -67,32 +201,103 @@ func runExperiment(g *game.Game) {
 	}
 
 	// Load Genome
-	log.Printf("Loading start genome for %s experiment from file '%s'\n", experimentName, genomePath)
-	reader, err := genetics.NewGenomeReaderFromFile(genomePath)
+	sensors, err := neapy.NewSensors(evalConfig.Sensors)
 	if err != nil {
-		log.Fatalf("Failed to open genome file, reason: '%s'", err)
+		log.Fatal("Invalid sensor manifest: ", err)
 	}
-	startGenome, err := reader.Read()
-
-	if err != nil {
-		log.Fatalf("Failed to read start genome, reason: '%s'", err)
+	genomePath := *startGenomeFile
+	var startGenome *genetics.Genome
+	if genomePath == "" {
+		genomePath = filepath.Join(*outDir, "start_genome.yaml")
+		log.Printf("Generating start genome for %s experiment into file '%s'\n", experimentName, genomePath)
+		startGenome = sensors.StarterGenome()
+		if err = os.MkdirAll(*outDir, os.ModePerm); err == nil {
+			err = neapy.SaveGenome(genomePath, startGenome)
+		}
+		if err != nil {
+			log.Fatalf("Failed to save start genome, reason: '%s'", err)
+		}
+	} else {
+		log.Printf("Loading start genome for %s experiment from file '%s'\n", experimentName, genomePath)
+		reader, err := genetics.NewGenomeReaderFromFile(genomePath)
+		if err != nil {
+			log.Fatalf("Failed to open genome file, reason: '%s'", err)
+		}
+		if startGenome, err = reader.Read(); err != nil {
+			log.Fatalf("Failed to read start genome, reason: '%s'", err)
+		}
 	}
-	fmt.Println(startGenome)
-
-	if err != nil {
-		log.Fatal("Failed to create output directory: ", err)
+	if err = sensors.CheckGenome(startGenome); err != nil {
+		log.Fatal("Start genome does not match the sensor manifest: ", err)
 	}
+	fmt.Println(startGenome)
 
 	// create experiment
-	expt := experiment.Experiment{
+	expt := &experiment.Experiment{
 		Id:       0,
 		Trials:   make(experiment.Trials, neatOptions.NumRuns),
 		RandSeed: 123,
 	}
-	var generationEvaluator experiment.GenerationEvaluator
+	// as given by fitness function definition
+	if expt.MaxFitnessScore, err = evalConfig.MaxFitness(game.WorldWidth); err != nil {
+		log.Fatal("Failed to compute max fitness: ", err)
+	}
+	var evaluator experiment.GenerationEvaluator
+	if g == nil {
+		evaluator, err = neapy.NewParallelEvaluator(game.WorldWidth, game.WorldHeight, evalConfig)
+	} else {
+		evaluator, err = neapy.NewFlappyEvaluator(g, evalConfig)
+	}
+	if err != nil {
+		log.Fatal("Failed to create evaluator: ", err)
+	}
+	championSaver, err := neapy.NewChampionSaver(*outDir, sensors, *resume)
+	if err != nil {
+		log.Fatal("Failed to create output directory: ", err)
+	}
+
+	// every run gets its own directory with the statistics, the results and the configs it was run with
+	runDir := filepath.Join(*outDir, "runs", time.Now().Format("20060102-150405"))
+	if err = os.MkdirAll(runDir, os.ModePerm); err != nil {
+		log.Fatal("Failed to create run directory: ", err)
+	}
+	neatConfig, err := os.ReadFile(contextPath)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(runDir, filepath.Base(contextPath)), neatConfig, 0o644)
+	}
+	if err != nil {
+		log.Fatal("Failed to copy NEAT options: ", err)
+	}
+	if err = evalConfig.WriteFile(filepath.Join(runDir, filepath.Base(*configFile))); err != nil {
+		log.Fatal("Failed to copy evaluator config: ", err)
+	}
+	if err = neapy.SaveGenome(filepath.Join(runDir, "start_genome.yaml"), startGenome); err != nil {
+		log.Fatal("Failed to copy start genome: ", err)
+	}
+	observers := neapy.Observers{championSaver}
+	if source, ok := evaluator.(neapy.StatsSource); ok {
+		statsRecorder, err := neapy.NewStatsRecorder(filepath.Join(runDir, "stats.csv"), source, evalConfig.Episodes)
+		if err != nil {
+			log.Fatal("Failed to create statistics file: ", err)
+		}
+		defer statsRecorder.Close()
+		observers = append(observers, statsRecorder)
+		if *dashboardAddr != "" {
+			// champions are replayed on the -seed course
+			server := dashboard.New(source, sensors, game.WorldWidth, game.WorldHeight, *seed)
+			server.Serve(*dashboardAddr)
+			observers = append(observers, server)
+		}
+	}
+	log.Printf("Writing run results into '%s'\n", runDir)
 
-	expt.MaxFitnessScore = 25000000.0 // as given by fitness function definition
-	generationEvaluator = neapy.NewFlappyEvaluator(g)
+	trainer := &neapy.Trainer{
+		Experiment:      expt,
+		Evaluator:       evaluator,
+		Observer:        observers,
+		CheckpointDir:   filepath.Join(*outDir, "checkpoints"),
+		CheckpointEvery: *checkpointEvery,
+	}
 
 	// prepare to execute
 	errChan := make(chan error)
@@ -101,7 +306,7 @@ func runExperiment(g *game.Game) {
 	fmt.Println("ready to execute")
 	// run experiment in the separate GO routine
 	go func() {
-		if err = expt.Execute(neat.NewContext(ctx, neatOptions), startGenome, generationEvaluator, nil); err != nil {
+		if err := trainer.Execute(neat.NewContext(ctx, neatOptions), startGenome, *resume); err != nil {
 			errChan <- err
 		} else {
 			errChan <- nil
@@ -119,7 +324,7 @@ func runExperiment(g *game.Game) {
 		case <-signals:
 			// signal to stop test fixture
 			cancel()
-		case err = <-errChan:
+		case <-ctx.Done():
 			// stop waiting
 		}
 	}(cancel)
@@ -127,7 +332,10 @@ func runExperiment(g *game.Game) {
 	// Wait for experiment completion
 	//
 	err = <-errChan
-	if err != nil {
+	cancel()
+	if errors.Is(err, context.Canceled) {
+		log.Printf("Experiment interrupted, continue it with -resume")
+	} else if err != nil {
 		// error during execution
 		log.Fatalf("Experiment execution failed: %s", err)
 	}
@@ -141,19 +349,23 @@ func runExperiment(g *game.Game) {
 
 	// Save experiment data in native format
 	//
-	// expResPath := fmt.Sprintf("%s/%s.dat", outDir, *experimentName)
-	// if expResFile, err := os.Create(expResPath); err != nil {
-	// 	log.Fatal("Failed to create file for experiment results", err)
-	// } else if err = expt.Write(expResFile); err != nil {
-	// 	log.Fatal("Failed to save experiment results", err)
-	// }
-
-	// Save experiment data in Numpy NPZ format if requested
+	expResPath := filepath.Join(runDir, experimentName+".dat")
+	if expResFile, err := os.Create(expResPath); err != nil {
+		log.Fatal("Failed to create file for experiment results", err)
+	} else if err = expt.Write(expResFile); err != nil {
+		log.Fatal("Failed to save experiment results", err)
+	} else {
+		_ = expResFile.Close()
+	}
+
+	// Save experiment data in Numpy NPZ format
 	//
-	// npzResPath := fmt.Sprintf("%s/%s.npz", outDir, *experimentName)
-	// if npzResFile, err := os.Create(npzResPath); err != nil {
-	// 	log.Fatalf("Failed to create file for experiment results: [%s], reason: %s", npzResPath, err)
-	// } else if err = expt.WriteNPZ(npzResFile); err != nil {
-	// 	log.Fatal("Failed to save experiment results as NPZ file", err)
-	// }
+	npzResPath := filepath.Join(runDir, experimentName+".npz")
+	if npzResFile, err := os.Create(npzResPath); err != nil {
+		log.Fatalf("Failed to create file for experiment results: [%s], reason: %s", npzResPath, err)
+	} else if err = expt.WriteNPZ(npzResFile); err != nil {
+		log.Fatal("Failed to save experiment results as NPZ file", err)
+	} else {
+		_ = npzResFile.Close()
+	}
 }
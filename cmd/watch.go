@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"gographics/game"
+	"gographics/neapy"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// loadChampion builds the controller from the -genome file or the champion saved by training.
+func loadChampion() *neapy.NetworkController {
+	path := *genomeFile
+	if path == "" {
+		path = filepath.Join(*outDir, "champion.json")
+	}
+	return loadController(path)
+}
+
+// loadController builds the controller from a genome YAML or network JSON file fed by the sensor manifest in -config.
+func loadController(path string) *neapy.NetworkController {
+	cfg, err := neapy.ReadConfigFromFile(*configFile)
+	if err != nil {
+		log.Fatal("Failed to load evaluator config: ", err)
+	}
+	sensors, err := neapy.NewSensors(cfg.Sensors)
+	if err != nil {
+		log.Fatal("Invalid sensor manifest: ", err)
+	}
+	c, err := neapy.LoadController(path, sensors)
+	if err != nil {
+		log.Fatalf("Failed to load network from '%s', reason: '%s'", path, err)
+	}
+	return c
+}
+
+// loadGhosts records the episodes of the -ghosts files on the -seed course.
+func loadGhosts() []game.Ghost {
+	var ghosts []game.Ghost
+	for _, path := range strings.Split(*ghostFiles, ",") {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+		c := loadController(path)
+		ghosts = append(ghosts, game.RecordGhost(filepath.Base(path), c, game.WorldWidth, game.WorldHeight, *seed, *maxSteps))
+	}
+	return ghosts
+}
+
+// runWatch lets the controller play in the window, restarting the game when it is over.
+func runWatch(g *game.Game, c game.Controller) {
+	g.SetSeed(*seed)
+	g.SetController(0, c)
+	for {
+		g.Restart(1)
+		g.Wait()
+		time.Sleep(time.Second)
+	}
+}
+
+// runEval plays the controller on -episodes seeded courses without a window and prints the results.
+func runEval(c game.Controller) {
+	g := game.NewGame(game.WorldWidth, game.WorldHeight, 1)
+	g.SetController(0, c)
+	total := 0
+	for i := 0; i < *episodes; i++ {
+		courseSeed := *seed
+		if courseSeed != 0 {
+			courseSeed += int64(i)
+		}
+		g.SetSeed(courseSeed)
+		g.Restart(1)
+		g.Run(*maxSteps)
+		rec := g.Records()[0]
+		total += rec.Pipes
+		fmt.Printf("seed %d: pipes %d, steps %d\n", courseSeed, rec.Pipes, rec.Steps)
+	}
+	fmt.Printf("mean pipes: %.2f\n", float64(total)/float64(*episodes))
+}
@@ -0,0 +1,192 @@
+package dashboard
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gographics/game"
+	"gographics/neapy"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/yaricom/goNEAT/v4/experiment"
+	"github.com/yaricom/goNEAT/v4/neat"
+)
+
+//go:embed index.html
+var indexHTML []byte
+
+const (
+	// replaySteps caps the length of the champion replay.
+	replaySteps = 3000
+	// historyLen is the number of recent generations kept for the charts.
+	historyLen = 1000
+)
+
+// Frame is what the champion saw on a single step of the replay.
+type Frame struct {
+	GopherY   float64 `json:"y"`
+	PipeBotY  float64 `json:"bot"`
+	PipeTopY  float64 `json:"top"`
+	PipeDistX float64 `json:"dist"`
+	Jump      bool    `json:"jump,omitempty"`
+}
+
+// Replay is an episode of the champion played anew on the replay course after it was evaluated,
+// not one of the episodes it was scored on.
+type Replay struct {
+	Generation int   `json:"generation"`
+	Seed       int64 `json:"seed"`
+	Pipes      int   `json:"pipes"`
+	// Capped is set when the champion was still alive after replaySteps steps.
+	Capped bool    `json:"capped,omitempty"`
+	Frames []Frame `json:"frames"`
+}
+
+// Server is a trial observer serving the training progress over HTTP: the statistics of recent generations,
+// the topology of the last champion and its replay.
+type Server struct {
+	source  neapy.StatsSource
+	sensors *neapy.Sensors
+	windowW int
+	windowH int
+	seed    int64
+
+	mu       sync.Mutex
+	history  []neapy.GenerationStats
+	champion *neapy.NetworkFile
+	replay   *Replay
+}
+
+// New creates the dashboard fed by the evaluator statistics. Champions are replayed on the course
+// of the given seed in a headless game of the given size.
+func New(source neapy.StatsSource, sensors *neapy.Sensors, windowW, windowH int, seed int64) *Server {
+	return &Server{source: source, sensors: sensors, windowW: windowW, windowH: windowH, seed: seed}
+}
+
+// ListenAndServe serves the dashboard on addr until the server fails.
+func (s *Server) ListenAndServe(addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handleIndex)
+	mux.HandleFunc("/api/state", s.handleState)
+	return http.ListenAndServe(addr, mux)
+}
+
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(indexHTML)
+}
+
+// state is the whole dashboard content, the page polls it.
+type state struct {
+	History  []neapy.GenerationStats `json:"history"`
+	Champion *neapy.NetworkFile      `json:"champion"`
+	Replay   *Replay                 `json:"replay"`
+}
+
+func (s *Server) handleState(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	data, err := json.Marshal(state{History: s.history, Champion: s.champion, Replay: s.replay})
+	s.mu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+func (s *Server) TrialRunStarted(*experiment.Trial) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history = nil
+}
+
+func (s *Server) TrialRunFinished(*experiment.Trial) {}
+
+func (s *Server) EpochEvaluated(trial *experiment.Trial, epoch *experiment.Generation) {
+	stats := s.source.GenerationStats().Complete(trial, epoch)
+	var (
+		champion *neapy.NetworkFile
+		replay   *Replay
+		err      error
+	)
+	if epoch.Champion != nil {
+		if champion, err = neapy.NewNetworkFile(epoch.Champion); err == nil {
+			champion.Sensors = s.sensors.Names()
+			replay, err = s.play(champion)
+		}
+		if err != nil {
+			neat.ErrorLog(fmt.Sprintf("Failed to replay the champion: %s", err))
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history = append(s.history, stats)
+	if len(s.history) > historyLen {
+		s.history = s.history[len(s.history)-historyLen:]
+	}
+	if replay != nil {
+		replay.Generation = epoch.Id
+		s.champion, s.replay = champion, replay
+	}
+}
+
+// play records the champion on the replay course. The network is built anew from the file,
+// so the organism phenotype is left alone.
+func (s *Server) play(nf *neapy.NetworkFile) (*Replay, error) {
+	net, err := nf.Network()
+	if err != nil {
+		return nil, err
+	}
+	if err = s.sensors.CheckNetwork(net); err != nil {
+		return nil, err
+	}
+	c := neapy.NewNetworkController(net, s.sensors)
+	rec := &recorder{Controller: c}
+	// seeded right away, so replaying does not draw from the random source the training is reseeded from
+	g := game.NewSeededGame(s.windowW, s.windowH, 1, s.seed)
+	g.SetController(0, rec)
+	g.Restart(1)
+	g.Run(replaySteps)
+	if err = c.Err(); err != nil {
+		return nil, err
+	}
+	last := g.Records()[0]
+	return &Replay{Seed: s.seed, Pipes: last.Pipes, Capped: last.Alive, Frames: rec.frames}, nil
+}
+
+// recorder keeps the observations of the controlled gopher.
+type recorder struct {
+	game.Controller
+	frames []Frame
+}
+
+func (r *recorder) Act(obs game.Observation) bool {
+	jump := r.Controller.Act(obs)
+	r.frames = append(r.frames, Frame{
+		GopherY:   obs.Gopher.PosYpercent,
+		PipeBotY:  obs.PipeBotY,
+		PipeTopY:  obs.PipeTopY,
+		PipeDistX: obs.PipeDistX,
+		Jump:      jump,
+	})
+	return jump
+}
+
+// Serve starts the dashboard in the background, a failing server is logged.
+func (s *Server) Serve(addr string) {
+	go func() {
+		log.Printf("Dashboard is served on http://%s\n", addr)
+		if err := s.ListenAndServe(addr); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Dashboard failed: %s", err)
+		}
+	}()
+}
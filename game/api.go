@@ -1,23 +1,10 @@
 package game
 
 import (
+	"context"
 	"errors"
-	"time"
 )
 
-// SyncInput puts input in the queue and blocks until input can be processed.
-// Returns false if input request was discarded
-func (g *Game) SyncInput(input map[int]bool) error {
-	for {
-		select {
-		case g.inpChan <- input:
-			return nil
-		case <-g.done:
-			return errors.New("game over")
-		}
-	}
-}
-
 // Restart forcefully restarts the game
 func (g *Game) Restart(gopherN int) {
 	g.restart(gopherN)
@@ -28,6 +15,7 @@ type State struct {
 	GophersState map[int]GopherState
 	PipeBotY     float64
 	PipeTopY     float64
+	PipeDistX    float64
 }
 
 type GopherState struct {
@@ -44,41 +32,6 @@ func (g *Game) CurState() (*State, error) {
 	return g.snapshotState(), nil
 }
 
-// NextState returns latest state of the game. Ensures new state for each request. Blocks until actual state is calculated.
-// Returns error if there won't be any more new states
-func (g *Game) NextState() (*State, error) {
-	g.activeRequests.Add(1)
-	defer g.activeRequests.Add(-1)
-	select {
-	case state := <-g.statesChan:
-		return state, nil
-	case <-g.done:
-		return nil, errors.New("game over")
-	}
-}
-
-func (g *Game) pushState() {
-	if g.activeRequests.Load() == 0 {
-		// no requests for this state
-		// fmt.Println("active req is 0")
-		if g.stepsPerUpdate > 1 {
-			g.stepsPerUpdate--
-		}
-		return
-	}
-	state := g.snapshotState()
-	// aviod locks anyway
-	select {
-	case g.statesChan <- state:
-		// pushed state
-	case <-time.After(time.Millisecond * 10):
-		// fmt.Println("no requests for this state")
-		// default:
-		// 	fmt.Println("no req")
-
-	}
-}
-
 func (g *Game) snapshotState() *State {
 	state := &State{
 		ID:           g.stepID,
@@ -92,6 +45,7 @@ func (g *Game) snapshotState() *State {
 		state.GophersState[gopher.ID] = gopherState
 	}
 	state.PipeBotY, state.PipeTopY = g.closestPipeYs()
+	state.PipeDistX = g.closestPipeDistX()
 	return state
 }
 
@@ -102,3 +56,70 @@ func (g *Game) closestPipeYs() (float64, float64) {
 	closest := g.pipesAhead[0]
 	return float64(closest.PosTopY()) / float64(g.windowH), float64(closest.PosBotY()) / float64(g.windowH)
 }
+
+// closestPipeDistX returns the distance from the gophers to the tail of the closest pipe ahead
+// as a fraction of the window width.
+func (g *Game) closestPipeDistX() float64 {
+	if len(g.pipesAhead) == 0 {
+		return 1
+	}
+	closest := g.pipesAhead[0]
+	return float64(closest.PosX()+closest.Width()-g.gophersX) / float64(g.windowW)
+}
+
+// Size returns the window size the game is played in.
+func (g *Game) Size() (int, int) {
+	return g.windowW, g.windowH
+}
+
+// SetSeed sets the course seed used from the next restart on. 0 means a new random course on every restart.
+func (g *Game) SetSeed(seed int64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.seed = seed
+}
+
+// SetPipeLimit ends the game once the given number of pipes was passed. 0 means no limit.
+func (g *Game) SetPipeLimit(pipes int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.pipeLimit = pipes
+}
+
+// Done returns the channel closed when the current game is over or restarted.
+func (g *Game) Done() <-chan bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.done
+}
+
+// Wait blocks until the current game is over or restarted.
+func (g *Game) Wait() {
+	<-g.Done()
+}
+
+// Run steps the game without rendering until it is over or maxSteps steps were made.
+// maxSteps <= 0 means no limit.
+func (g *Game) Run(maxSteps int) {
+	_ = g.RunContext(context.Background(), maxSteps)
+}
+
+// RunContext is Run stopping early with the context error once ctx is done.
+func (g *Game) RunContext(ctx context.Context, maxSteps int) error {
+	for i := 0; maxSteps <= 0 || i < maxSteps; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		g.Step()
+		if g.over() {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (g *Game) over() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.mode == ModeGameOver
+}
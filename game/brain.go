@@ -0,0 +1,142 @@
+package game
+
+import (
+	"image/color"
+	"math"
+	"sort"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+type BrainNodeKind int
+
+const (
+	BrainInput BrainNodeKind = iota
+	BrainBias
+	BrainHidden
+	BrainOutput
+)
+
+// BrainNode is a neuron of a network drawn in the overlay.
+type BrainNode struct {
+	ID    int
+	Kind  BrainNodeKind
+	Label string
+}
+
+// BrainLink is a connection of a network drawn in the overlay.
+type BrainLink struct {
+	From   int
+	To     int
+	Weight float64
+}
+
+// Brain is a controller whose network can be drawn over the game.
+type Brain interface {
+	Controller
+	Topology() ([]BrainNode, []BrainLink)
+	// Activations returns the activation of every node at the last step by node id.
+	Activations() map[int]float64
+}
+
+// brain overlay placement
+const (
+	brainX       = 10
+	brainW       = 260
+	brainH       = 180
+	brainNodeR   = 6
+	brainPadding = 30
+)
+
+// ToggleBrain shows or hides the network overlay.
+func (g *Game) ToggleBrain() {
+	g.showBrain = !g.showBrain
+}
+
+// focusedBrain returns the brain of the gopher followed by the spectator mode, otherwise the brain of the alive
+// gopher with the lowest id, if any gopher has one. g.mu must be held.
+func (g *Game) focusedBrain() Brain {
+	if _, alive := g.gophers[g.focusedID]; g.follow && alive {
+		if b, ok := g.controllers[g.focusedID].(Brain); ok {
+			return b
+		}
+	}
+	ids := make([]int, 0, len(g.gophers))
+	for id := range g.gophers {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		if b, ok := g.controllers[id].(Brain); ok {
+			return b
+		}
+	}
+	return nil
+}
+
+// drawBrain draws the brain of the focused gopher below brainY: links coloured by weight sign and as thick as the weight is
+// strong, nodes coloured by their activation at the last step.
+func (g *Game) drawBrain(screen *ebiten.Image, b Brain, brainY int) {
+	if b == nil {
+		return
+	}
+	nodes, links := b.Topology()
+	activations := b.Activations()
+	vector.DrawFilledRect(screen, brainX, float32(brainY), brainW, brainH, color.RGBA{0, 0, 0, 0x80}, false)
+
+	columns := make([][]BrainNode, 3)
+	for _, n := range nodes {
+		col := 1
+		switch n.Kind {
+		case BrainInput, BrainBias:
+			col = 0
+		case BrainOutput:
+			col = 2
+		}
+		columns[col] = append(columns[col], n)
+	}
+	type point struct{ x, y float64 }
+	pos := make(map[int]point, len(nodes))
+	for col, column := range columns {
+		x := float64(brainX+brainPadding) + float64(col)*float64(brainW-2*brainPadding)/2
+		for i, n := range column {
+			y := float64(brainY) + float64(i+1)*float64(brainH)/float64(len(column)+1)
+			pos[n.ID] = point{x, y}
+		}
+	}
+
+	for _, l := range links {
+		from, ok1 := pos[l.From]
+		to, ok2 := pos[l.To]
+		if !ok1 || !ok2 {
+			continue
+		}
+		clr := color.RGBA{0x40, 0xc0, 0x40, 0xc0}
+		if l.Weight < 0 {
+			clr = color.RGBA{0xc0, 0x40, 0x40, 0xc0}
+		}
+		DrawThickLine(screen, from.x, from.y, to.x, to.y, 0.5+math.Min(math.Abs(l.Weight), 3), clr)
+	}
+	for _, n := range nodes {
+		p := pos[n.ID]
+		vector.DrawFilledCircle(screen, float32(p.x), float32(p.y), brainNodeR, activationColor(activations[n.ID]), true)
+		switch n.Kind {
+		case BrainOutput:
+			ebitenutil.DebugPrintAt(screen, n.Label, int(p.x)-brainNodeR-6*len(n.Label), int(p.y)+brainNodeR)
+		default:
+			ebitenutil.DebugPrintAt(screen, n.Label, int(p.x)+brainNodeR+2, int(p.y)-8)
+		}
+	}
+}
+
+// activationColor fades from white at 0 to red for positive and blue for negative activations.
+func activationColor(a float64) color.Color {
+	a = math.Max(-1, math.Min(1, a))
+	fade := uint8(255 * (1 - math.Abs(a)))
+	if a >= 0 {
+		return color.RGBA{255, fade, fade, 255}
+	}
+	return color.RGBA{fade, fade, 255, 255}
+}
@@ -0,0 +1,242 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// CaptureOptions configure the recording of the window frames.
+type CaptureOptions struct {
+	// Dir is where recordings are written, every one named by its start time.
+	Dir string
+	// GIF encodes an animated GIF instead of a sequence of PNG frames.
+	GIF bool
+	// Skip is the number of frames skipped after every captured one.
+	Skip int
+	// Scale is the size of the captured frames relative to the window.
+	Scale float64
+}
+
+const (
+	// captureQueue is the number of captured frames waiting to be encoded before Draw blocks.
+	captureQueue = 64
+	// gifMaxFrames caps GIF recordings, their frames are kept in memory until the recording stops.
+	// Longer recordings are made as PNG frames, which are written as they come.
+	gifMaxFrames = 600
+)
+
+// capture records the drawn frames, they are encoded in the background.
+type capture struct {
+	opts CaptureOptions
+	// path is the GIF file or the directory of the PNG frames.
+	path  string
+	ticks int
+	// captured is the number of frames sent to the encoder.
+	captured int
+	scaled   *ebiten.Image
+	frames   chan *image.RGBA
+	done     chan error
+}
+
+func newCapture(opts CaptureOptions) (*capture, error) {
+	if opts.Skip < 0 {
+		return nil, errors.New("frame skip must not be negative")
+	}
+	if opts.Scale <= 0 {
+		return nil, errors.New("capture scale must be positive")
+	}
+	path := filepath.Join(opts.Dir, time.Now().Format("20060102-150405"))
+	dir := path
+	if opts.GIF {
+		path += ".gif"
+		dir = opts.Dir
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	c := &capture{
+		opts:   opts,
+		path:   path,
+		frames: make(chan *image.RGBA, captureQueue),
+		done:   make(chan error, 1),
+	}
+	go c.encode()
+	return c, nil
+}
+
+// frame captures the screen unless the frame is skipped or the GIF is full. It blocks Draw once
+// captureQueue frames wait to be encoded, so a slow encoder slows the game down instead of losing frames.
+func (c *capture) frame(screen *ebiten.Image) {
+	c.ticks++
+	if (c.ticks-1)%(c.opts.Skip+1) != 0 {
+		return
+	}
+	if c.opts.GIF && c.captured == gifMaxFrames {
+		return
+	}
+	c.captured++
+	if c.opts.GIF && c.captured == gifMaxFrames {
+		log.Printf("Recording '%s' reached %d frames, the rest is not recorded\n", c.path, gifMaxFrames)
+	}
+	src := screen
+	if c.opts.Scale != 1 {
+		w := int(math.Round(float64(screen.Bounds().Dx()) * c.opts.Scale))
+		h := int(math.Round(float64(screen.Bounds().Dy()) * c.opts.Scale))
+		if c.scaled == nil || c.scaled.Bounds().Dx() != w || c.scaled.Bounds().Dy() != h {
+			c.scaled = ebiten.NewImage(max(w, 1), max(h, 1))
+		}
+		c.scaled.Clear()
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(c.opts.Scale, c.opts.Scale)
+		op.Filter = ebiten.FilterLinear
+		c.scaled.DrawImage(screen, op)
+		src = c.scaled
+	}
+	img := image.NewRGBA(image.Rect(0, 0, src.Bounds().Dx(), src.Bounds().Dy()))
+	src.ReadPixels(img.Pix)
+	c.frames <- img
+}
+
+// encode writes the PNG frames as they come or collects the GIF frames and writes them at the end.
+func (c *capture) encode() {
+	var (
+		anim gif.GIF
+		err  error
+	)
+	// frames are drawn at 60 FPS, GIF delays are in 100ths of a second
+	delay := max(2, int(math.Round(float64(c.opts.Skip+1)*100/60)))
+	n := 0
+	for img := range c.frames {
+		if err != nil {
+			// drain the queue, so Draw is not blocked
+			continue
+		}
+		if c.opts.GIF {
+			frame := image.NewPaletted(img.Bounds(), palette.Plan9)
+			draw.FloydSteinberg.Draw(frame, img.Bounds(), img, image.Point{})
+			anim.Image = append(anim.Image, frame)
+			anim.Delay = append(anim.Delay, delay)
+		} else {
+			err = writePNG(filepath.Join(c.path, fmt.Sprintf("frame_%05d.png", n)), img)
+		}
+		n++
+	}
+	if err == nil && c.opts.GIF && len(anim.Image) > 0 {
+		err = writeGIF(c.path, &anim)
+	}
+	c.done <- err
+}
+
+func writePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = png.Encode(f, img); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeGIF(path string, anim *gif.GIF) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = gif.EncodeAll(f, anim); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// SetCaptureOptions sets how recordings started from now on are made.
+func (g *Game) SetCaptureOptions(opts CaptureOptions) {
+	g.muDraw.Lock()
+	defer g.muDraw.Unlock()
+	g.captureOpts = opts
+}
+
+// StartRecording starts capturing the drawn frames, it does nothing if the game is already recorded.
+func (g *Game) StartRecording() error {
+	g.muDraw.Lock()
+	defer g.muDraw.Unlock()
+	if g.capture != nil {
+		return nil
+	}
+	c, err := newCapture(g.captureOpts)
+	if err != nil {
+		return err
+	}
+	g.capture = c
+	log.Printf("Recording into '%s'\n", c.path)
+	return nil
+}
+
+// StopRecording stops capturing and blocks until the recording and the ones stopped with R are written.
+func (g *Game) StopRecording() error {
+	g.muDraw.Lock()
+	c := g.capture
+	g.capture = nil
+	g.muDraw.Unlock()
+	defer g.finishing.Wait()
+	if c == nil {
+		return nil
+	}
+	return c.finish()
+}
+
+// finish stops the capture and waits until every captured frame is written.
+func (c *capture) finish() error {
+	close(c.frames)
+	if err := <-c.done; err != nil {
+		return fmt.Errorf("failed to write recording %s: %w", c.path, err)
+	}
+	log.Printf("Recording is written into '%s'\n", c.path)
+	return nil
+}
+
+// toggleRecording starts or stops the recording, failures are logged.
+func (g *Game) toggleRecording() {
+	g.muDraw.Lock()
+	c := g.capture
+	g.capture = nil
+	g.muDraw.Unlock()
+	if c == nil {
+		if err := g.StartRecording(); err != nil {
+			log.Printf("Failed to start recording: %s", err)
+		}
+		return
+	}
+	// the GIF is encoded at the end, the game keeps running meanwhile
+	g.finishing.Add(1)
+	go func() {
+		defer g.finishing.Done()
+		if err := c.finish(); err != nil {
+			log.Println(err)
+		}
+	}()
+}
+
+// drawRecording captures the finished frame and marks the window as recorded, the mark is not captured.
+func (g *Game) drawRecording(screen *ebiten.Image) {
+	if g.capture == nil {
+		return
+	}
+	g.capture.frame(screen)
+	ebitenutil.DebugPrintAt(screen, "REC", screen.Bounds().Dx()/2-10, 0)
+}
@@ -0,0 +1,140 @@
+package game
+
+import (
+	"math/rand"
+)
+
+// Observation is what a controller sees of the game on a single step.
+type Observation struct {
+	// Step is the number of steps since the game start.
+	Step   int
+	Gopher GopherState
+	// PipeBotY is the bottom of the closest top pipe, PipeTopY is the top of the closest bottom pipe.
+	PipeBotY float64
+	PipeTopY float64
+	// PipeDistX is the distance to the tail of the closest pipe ahead as a fraction of the window width.
+	PipeDistX float64
+}
+
+// Observation returns what the gopher with given id sees in the state.
+// Returns false if the gopher is not alive.
+func (s *State) Observation(id int) (Observation, bool) {
+	gopher, ok := s.GophersState[id]
+	if !ok {
+		return Observation{}, false
+	}
+	return Observation{
+		Step:      s.ID,
+		Gopher:    gopher,
+		PipeBotY:  s.PipeBotY,
+		PipeTopY:  s.PipeTopY,
+		PipeDistX: s.PipeDistX,
+	}, true
+}
+
+// Controller is the brain of a single gopher.
+type Controller interface {
+	// Act is called every step the gopher is alive and reports whether it jumps.
+	Act(obs Observation) bool
+}
+
+// SetController attaches the controller to the gopher with given id. Controllers are kept between restarts.
+// nil detaches the controller, leaving the gopher without input.
+func (g *Game) SetController(id int, c Controller) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if c == nil {
+		delete(g.controllers, id)
+		return
+	}
+	if g.controllers == nil {
+		g.controllers = make(map[int]Controller)
+	}
+	g.controllers[id] = c
+}
+
+// runControllers lets every alive gopher with a controller decide on a jump.
+func (g *Game) runControllers() {
+	if len(g.controllers) == 0 {
+		return
+	}
+	state := g.snapshotState()
+	for id, c := range g.controllers {
+		obs, ok := state.Observation(id)
+		if ok && c.Act(obs) {
+			g.jump(g.gophers[id])
+		}
+	}
+}
+
+// HumanController jumps when the player pressed a jump input since the last step.
+type HumanController struct {
+	pressed bool
+}
+
+// Press registers a jump input, it is consumed by the next step.
+func (h *HumanController) Press() {
+	h.pressed = true
+}
+
+func (h *HumanController) Act(Observation) bool {
+	jump := h.pressed
+	h.pressed = false
+	return jump
+}
+
+// ScriptedController is a hand written heuristic: it jumps whenever the gopher falls below the gap centre.
+type ScriptedController struct{}
+
+func (ScriptedController) Act(obs Observation) bool {
+	gapCentre := (obs.PipeBotY + obs.PipeTopY) / 2
+	return obs.Gopher.SpeedY > 0 && obs.Gopher.PosYpercent > gapCentre
+}
+
+// RandomController jumps at random with the given probability per step.
+type RandomController struct {
+	rng  *rand.Rand
+	prob float64
+}
+
+func NewRandomController(seed int64, prob float64) *RandomController {
+	return &RandomController{
+		rng:  rand.New(rand.NewSource(seed)),
+		prob: prob,
+	}
+}
+
+func (r *RandomController) Act(Observation) bool {
+	return r.rng.Float64() < r.prob
+}
+
+// Recorder wraps a controller and records the steps it jumped on, so the run can be replayed.
+type Recorder struct {
+	Controller
+	Jumps []int
+}
+
+func (r *Recorder) Act(obs Observation) bool {
+	jump := r.Controller.Act(obs)
+	if jump {
+		r.Jumps = append(r.Jumps, obs.Step)
+	}
+	return jump
+}
+
+// ReplayController jumps on the recorded steps. Replays are exact only on the same course.
+type ReplayController struct {
+	jumps map[int]bool
+}
+
+func NewReplayController(jumps []int) *ReplayController {
+	r := &ReplayController{jumps: make(map[int]bool, len(jumps))}
+	for _, step := range jumps {
+		r.jumps[step] = true
+	}
+	return r
+}
+
+func (r *ReplayController) Act(obs Observation) bool {
+	return r.jumps[obs.Step]
+}
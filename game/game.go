@@ -28,6 +28,15 @@ const (
 	smallFontSize = fontSize / 2
 )
 
+// course geometry
+const (
+	gophersX       = 120
+	gopherStartX   = 100
+	scrollSpeed    = 3
+	pipeSpawnDelay = 110
+	pipeGap        = 180
+)
+
 var (
 	gopherImage     *ebiten.Image
 	tilesImage      *ebiten.Image
@@ -74,28 +83,82 @@ func init() {
 type GameMode string
 
 const (
+	ModeTitle    GameMode = "title"
 	ModePlay     GameMode = "play"
+	ModePause    GameMode = "pause"
 	ModeGameOver GameMode = "gameover"
 )
 
 type Game struct {
 	// meta
-	mode           GameMode
-	stepID         int
-	mu             sync.Mutex
-	muDraw         sync.Mutex
-	score          int
-	dynamicSPU     bool
-	stepsPerUpdate int
-	resetsNum      int
-
-	// window
+	mode      GameMode
+	stepID    int
+	mu        sync.Mutex
+	muDraw    sync.Mutex
+	score     int
+	resetsNum int
+
+	// speed control
+	simSpeed    float64
+	simBudget   float64
+	simPaused   atomic.Bool
+	simStep     atomic.Bool
+	renderEvery int
+
+	// human player
+	human         bool
+	player        *HumanController
+	gameOverTicks int
+	racers        int
+
+	// gopher brains
+	controllers map[int]Controller
+	showBrain   bool
+
+	// rendering
+	post       *PostProcess
+	infos      map[int]GopherInfo
+	tintMode   TintMode
+	showLabels bool
+	hud        []HUDItem
+	stats      StatsProvider
+	// spectator
+	follow    bool
+	focusID   int
+	focusedID int
+	trail     []trailPoint
+	pipBuf    *ebiten.Image
+
+	// scene is what is drawn when the simulation is not detached, scenes hand it over when it is.
+	scene  scene
+	scenes *sceneBuffer
+
+	// recording, finishing tracks the recordings stopped with R which are still being written
+	capture     *capture
+	captureOpts CaptureOptions
+	finishing   sync.WaitGroup
+
+	// course
+	seed      int64
+	rng       *rand.Rand
+	pipeLimit int
+
+	// world size, the course is simulated in
 	windowW int
 	windowH int
 
+	// logical resolution the world is scaled to
+	resW     int
+	resH     int
+	resSet   bool
+	worldBuf *ebiten.Image
+
 	// gopher
-	gophers  map[int]*Gopher
-	gophersX int
+	gophers   map[int]*Gopher
+	ghosts    []Ghost
+	ghostRuns []*ghostRun
+	records   map[int]*Record
+	gophersX  int
 
 	// general scrollX speed
 	speed int
@@ -111,17 +174,25 @@ type Game struct {
 	base *Base
 
 	// api
-	inpChan chan map[int]bool
-
-	statesChan     chan *State
-	activeRequests atomic.Int32
-	done           chan bool
+	done chan bool
 }
 
+// NewGame creates a game in a world of the given size, WorldWidth and WorldHeight keep courses comparable.
 func NewGame(windowW, windowHeight int, gopherN int) *Game {
+	return NewSeededGame(windowW, windowHeight, gopherN, 0)
+}
+
+// NewSeededGame creates a game on the course of the seed, as after SetSeed. Unlike NewGame it does not
+// draw a course from the global math/rand source, so creating headless games does not change seeded runs.
+func NewSeededGame(windowW, windowHeight int, gopherN int, seed int64) *Game {
 	g := &Game{
-		windowW: windowW,
-		windowH: windowHeight,
+		windowW:     windowW,
+		windowH:     windowHeight,
+		hud:         HUDItems,
+		simSpeed:    defaultSpeed,
+		renderEvery: 1,
+		focusID:     leaderFocus,
+		seed:        seed,
 	}
 	g.restart(gopherN)
 	return g
@@ -135,62 +206,61 @@ func (g *Game) restart(gopherN int) {
 	if g.done != nil && g.mode != ModeGameOver {
 		close(g.done)
 	}
-	g.dynamicSPU = false
 	g.mode = ModePlay
+	if g.human {
+		g.mode = ModeTitle
+	}
+	seed := g.seed
+	if seed == 0 {
+		seed = rand.Int63()
+	}
+	// pipes get their own source, so the course does not depend on the number of gophers
+	g.rng = rand.New(rand.NewSource(seed))
+	spawnRng := rand.New(rand.NewSource(^seed))
 	g.gophers = make(map[int]*Gopher, gopherN)
-	g.gophersX = 120
+	g.records = make(map[int]*Record, gopherN)
+	g.gophersX = gophersX
 	g.stepID = 0
 	for i := 0; i < gopherN; i++ {
-		g.gophers[i] = NewGopher(i, 100, g.windowH*3/4-gopherImage.Bounds().Dy()/2-rand.Intn(g.windowH/2))
+		y := g.windowH*3/4 - gopherImage.Bounds().Dy()/2 - spawnRng.Intn(g.windowH/2)
+		if g.racers > 0 {
+			// fair start for everyone in the race
+			y = (g.windowH - gopherImage.Bounds().Dy()) / 2
+		}
+		g.gophers[i] = NewGopher(i, gopherStartX, y)
+		g.records[i] = &Record{ID: i, Alive: true}
+		if g.racers > 0 && i != humanID {
+			g.gophers[i].SetTint(racerTint)
+		}
 	}
+	g.startGhosts(seed)
+	g.trail = g.trail[:0]
 	g.resetsNum++
 	// g.gophers[2] = NewGopher(2, 200, 100)
 
 	g.spawnTimer = 0
 	g.score = 0
-	g.stepsPerUpdate = 1
-	g.inpChan = make(chan map[int]bool)
-	g.statesChan = make(chan *State)
 	g.done = make(chan bool)
-	g.spawnDelay = 110
-	g.gapY = 180
+	g.spawnDelay = pipeSpawnDelay
+	g.gapY = pipeGap
 	g.pipes = make([]*Pipe, 0)
 	g.pipesAhead = make([]*Pipe, 0)
-	g.speed = 3
+	g.speed = scrollSpeed
 	g.base = NewBase(g.windowH, g.speed)
 }
 
 func (g *Game) GameOver() {
 	g.mode = ModeGameOver
-
-	// close(g.inpChan)
 	close(g.done)
 }
 
-func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
-	return g.windowW, g.windowH
-}
-
 func (g *Game) Step() {
 	g.mu.Lock()
-	defer func() {
-		g.mu.Unlock()
-		g.stepID++
-	}()
+	defer g.mu.Unlock()
 	switch g.mode {
 	case ModePlay:
-		// process input
-		select {
-		case inp := <-g.inpChan:
-			for id, jump := range inp {
-				gopher, ok := g.gophers[id]
-				if ok && jump {
-					gopher.Jump()
-				}
-			}
-		default:
-			// fmt.Println("no input this tick...")
-		}
+		g.runControllers()
+		g.moveGhosts()
 
 		for _, gopher := range g.gophers {
 			gopher.Move()
@@ -214,6 +284,7 @@ func (g *Game) Step() {
 			p0 := g.pipesAhead[0]
 			if p0.Passed(g.gophersX) {
 				g.score++
+				g.recordPass(p0)
 				g.pipesAhead = g.pipesAhead[1:]
 			}
 		}
@@ -222,24 +293,29 @@ func (g *Game) Step() {
 		for _, pipe := range g.pipes {
 			for _, gopher := range g.gophers {
 				if pipe.Collide(gopher) || gopher.OffScreenY(g.windowH-tileSize) {
-					delete(g.gophers, gopher.ID)
+					g.kill(gopher)
 				}
 			}
 		}
-		if len(g.gophers) == 0 {
+		g.collideGhosts()
+		g.recordStep()
+		if g.follow {
+			g.updateFocus()
+		}
+		if len(g.gophers) == 0 || g.raceLost() || g.pipeLimit > 0 && g.score >= g.pipeLimit {
 			g.GameOver()
 		}
+		// only played steps are counted, under the lock, so a restart always starts from step 0
+		g.stepID++
 	case ModeGameOver:
 		// do nothing until reset
 	}
-
-	g.pushState()
 }
 
 func (g *Game) SpawnPipe() {
 	if g.spawnTimer <= 0 {
 		g.spawnTimer = g.spawnDelay
-		newPipe := NewPipe(g.windowW, g.windowH, g.gapY, g.speed)
+		newPipe := NewPipe(g.rng, g.windowW, g.windowH, g.gapY, g.speed)
 		g.pipes = append(g.pipes, newPipe)
 		g.pipesAhead = append(g.pipesAhead, newPipe)
 	}
@@ -247,42 +323,128 @@ func (g *Game) SpawnPipe() {
 }
 
 func (g *Game) Update() error {
-	if g.dynamicSPU {
-		for i := 0; i < g.stepsPerUpdate; i++ {
-			g.Step()
-		}
-
-		if ebiten.ActualTPS() < 50 {
-			if g.stepsPerUpdate > 1 {
-				g.stepsPerUpdate--
-			}
-		} else {
-			g.stepsPerUpdate++
-		}
-	} else {
-		g.Step()
-		g.Step()
-		// g.Step()
+	if brainPressed() {
+		g.ToggleBrain()
+	}
+	if shadersPressed() && g.post != nil {
+		g.post.Toggle()
 	}
+	if tintPressed() {
+		g.CycleTint()
+	}
+	if labelsPressed() {
+		g.ToggleLabels()
+	}
+	if recordPressed() {
+		g.toggleRecording()
+	}
+	if fullscreenPressed() {
+		ToggleFullscreen()
+	}
+	if followPressed() {
+		g.ToggleFollow()
+	}
+	if dir := focusCycled(); dir != 0 {
+		g.CycleFocus(dir)
+	}
+	if g.human {
+		g.updateHuman()
+		return nil
+	}
+	g.updateSpeed()
+	if g.scenes != nil {
+		// the detached simulation steps itself
+		return nil
+	}
+	g.advance()
 	return nil
 }
 
 func (g *Game) Draw(screen *ebiten.Image) {
 	g.muDraw.Lock()
 	defer g.muDraw.Unlock()
+	var sc *scene
+	if g.scenes != nil {
+		sc = g.scenes.take()
+	} else {
+		g.mu.Lock()
+		g.fillScene(&g.scene)
+		g.mu.Unlock()
+		sc = &g.scene
+	}
+	if sc == nil {
+		// the detached simulation has not published anything yet
+		return
+	}
+	world := g.worldTarget(screen)
+	if !g.post.active() {
+		g.drawScene(world, sc)
+	} else {
+		g.drawScene(g.post.target(world.Bounds().Dx(), world.Bounds().Dy()), sc)
+		g.post.apply(world)
+	}
+	if world != screen {
+		g.present(screen, world)
+	}
+	g.drawRecording(screen)
+}
+
+// drawScene draws the game itself, the screen may be the offscreen image of the post-processing.
+func (g *Game) drawScene(screen *ebiten.Image, sc *scene) {
 	screen.Fill(color.RGBA{0x80, 0xa0, 0xc0, 0xff})
-	for _, pipe := range g.pipes {
-		pipe.Draw(screen)
+	if !g.human && g.fastForward() {
+		g.drawHUD(screen, sc)
+		l := "FAST-FORWARD"
+		text.Draw(screen, l, arcadeFont, (g.windowW-len(l)*fontSize)/2, g.windowH/2, color.White)
+		return
+	}
+	for i := range sc.pipes {
+		sc.pipes[i].Draw(screen)
 	}
-	for _, gopher := range g.gophers {
+	for i := range sc.ghosts {
+		drawGhost(screen, &sc.ghosts[i])
+	}
+	for i := range sc.gophers {
+		gopher := &sc.gophers[i]
+		if g.racers == 0 {
+			gopher.SetTint(g.gopherTint(gopher))
+		}
 		gopher.Draw(screen)
+		if g.showLabels {
+			g.drawLabel(screen, gopher)
+		}
 	}
-	g.base.Draw(screen)
+	focused := sc.focusedGopher()
+	if focused != nil {
+		drawFocus(screen, sc, focused)
+	}
+	sc.base.Draw(screen)
+	if focused != nil {
+		g.drawPictureInPicture(screen, focused, sc.leaderFocused)
+	}
+	brainY := hudY
+	if !g.human {
+		brainY = g.drawHUD(screen, sc) + 4
+	}
+	if g.showBrain {
+		g.drawBrain(screen, sc.brain, brainY)
+	}
+	g.drawLegend(screen, sc)
 
 	var titleTexts []string
 	var texts []string
-	if g.mode == ModeGameOver {
+	switch sc.mode {
+	case ModeTitle:
+		titleTexts = []string{"FLAPPY GOPHER"}
+		texts = []string{"", "", "", "", "PRESS SPACE KEY", "", "OR CLICK", "", "OR TOUCH SCREEN"}
+	case ModePause:
+		texts = []string{"", "PAUSED"}
+	case ModeGameOver:
 		texts = []string{"", "GAME OVER!"}
+		texts = append(texts, sc.raceResults...)
+		if g.human && g.gameOverTicks > gameOverDelay {
+			texts = append(texts, "", "", "PRESS TO RETRY")
+		}
 	}
 
 	// texts
@@ -295,10 +457,7 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		text.Draw(screen, l, arcadeFont, x, (i+4)*fontSize, color.White)
 	}
 
-	scoreStr := fmt.Sprintf("%04d", g.Score())
-	text.Draw(screen, scoreStr, arcadeFont, g.windowW-len(scoreStr)*fontSize, fontSize, color.White)
-	resetsStr := fmt.Sprintf("Gen: %d", g.resetsNum)
-	text.Draw(screen, resetsStr, arcadeFont, 10, 2*fontSize, color.White)
+	scoreStr := fmt.Sprintf("%04d", sc.score)
 	text.Draw(screen, scoreStr, arcadeFont, g.windowW-len(scoreStr)*fontSize, fontSize, color.White)
 	ebitenutil.DebugPrint(screen, fmt.Sprintf("TPS: %0.2f", ebiten.ActualTPS()))
 }
@@ -0,0 +1,110 @@
+package game
+
+import (
+	"image/color"
+	"sort"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// ghostTint makes ghosts semi-transparent.
+var ghostTint = color.NRGBA{0xff, 0xff, 0xff, 0x60}
+
+// Ghost is a recorded episode replayed alongside the live gophers on the course it was recorded on.
+type Ghost struct {
+	Name   string
+	Seed   int64
+	StartY int
+	// Jumps are the steps the gopher jumped on in ascending order.
+	Jumps []int
+}
+
+// ghostRun is a ghost replayed in the current game.
+type ghostRun struct {
+	ghost  *Ghost
+	gopher *Gopher
+	// next is the index of the next jump
+	next int
+}
+
+// ghostView is a ghost as it is drawn.
+type ghostView struct {
+	Gopher
+	name string
+}
+
+// RecordGhost plays the controller alone on the seeded course without rendering and records the episode,
+// maxSteps <= 0 means no limit.
+func RecordGhost(name string, c Controller, windowW, windowH int, seed int64, maxSteps int) Ghost {
+	rec := &Recorder{Controller: c}
+	g := NewSeededGame(windowW, windowH, 1, seed)
+	g.SetController(0, rec)
+	g.Restart(1)
+	startY := g.gophers[0].PosY()
+	g.Run(maxSteps)
+	return Ghost{Name: name, Seed: seed, StartY: startY, Jumps: rec.Jumps}
+}
+
+// SetGhosts sets the ghosts replayed from the next restart on, or right away if the game did not start yet.
+// Only the ghosts recorded on the course of the game are shown, they do not affect collisions or scoring.
+func (g *Game) SetGhosts(ghosts []Ghost) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.ghosts = make([]Ghost, len(ghosts))
+	for i, gh := range ghosts {
+		g.ghosts[i] = gh
+		g.ghosts[i].Jumps = append([]int(nil), gh.Jumps...)
+		sort.Ints(g.ghosts[i].Jumps)
+	}
+	if g.stepID == 0 && g.seed != 0 {
+		g.startGhosts(g.seed)
+	}
+}
+
+// startGhosts starts replaying the ghosts recorded on the course of the seed. g.mu must be held.
+func (g *Game) startGhosts(seed int64) {
+	g.ghostRuns = g.ghostRuns[:0]
+	for i := range g.ghosts {
+		gh := &g.ghosts[i]
+		if gh.Seed == seed {
+			g.ghostRuns = append(g.ghostRuns, &ghostRun{ghost: gh, gopher: NewGopher(-1-i, gopherStartX, gh.StartY)})
+		}
+	}
+}
+
+// moveGhosts jumps the ghosts on their recorded steps and moves them like gophers.
+func (g *Game) moveGhosts() {
+	for _, run := range g.ghostRuns {
+		jumps := run.ghost.Jumps
+		for run.next < len(jumps) && jumps[run.next] < g.stepID {
+			run.next++
+		}
+		if run.next < len(jumps) && jumps[run.next] == g.stepID {
+			run.gopher.Jump()
+		}
+		run.gopher.Move()
+	}
+}
+
+// collideGhosts removes the ghosts hitting a pipe or leaving the screen, just like the gophers they were.
+func (g *Game) collideGhosts() {
+	alive := g.ghostRuns[:0]
+	for _, run := range g.ghostRuns {
+		hit := run.gopher.OffScreenY(g.windowH - tileSize)
+		for _, pipe := range g.pipes {
+			hit = hit || pipe.Collide(run.gopher)
+		}
+		if !hit {
+			alive = append(alive, run)
+		}
+	}
+	g.ghostRuns = alive
+}
+
+// drawGhost draws the ghost semi-transparent with its name.
+func drawGhost(screen *ebiten.Image, gh *ghostView) {
+	gh.SetTint(ghostTint)
+	gh.Draw(screen)
+	ebitenutil.DebugPrintAt(screen, gh.name, gh.PosX(), gh.PosY()+gh.Height())
+}
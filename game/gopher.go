@@ -1,6 +1,7 @@
 package game
 
 import (
+	"image/color"
 	"math"
 
 	"github.com/hajimehoshi/ebiten/v2"
@@ -11,6 +12,7 @@ type Gopher struct {
 	x      float64
 	y      float64
 	speedY float64
+	tint   color.Color
 }
 
 func NewGopher(ID int, x, y int) *Gopher {
@@ -22,6 +24,11 @@ func NewGopher(ID int, x, y int) *Gopher {
 	}
 }
 
+// SetTint sets the colour the gopher image is multiplied by. nil draws the original image.
+func (g *Gopher) SetTint(c color.Color) {
+	g.tint = c
+}
+
 func (g *Gopher) Jump() {
 	g.speedY = -6
 }
@@ -66,6 +73,9 @@ func (g *Gopher) Draw(screen *ebiten.Image) {
 	op.GeoM.Translate(float64(w)/2.0, float64(h)/2.0)
 	op.GeoM.Translate(float64(g.x), float64(g.y))
 	op.Filter = ebiten.FilterLinear
+	if g.tint != nil {
+		op.ColorScale.ScaleWithColor(g.tint)
+	}
 	// screen.DrawImage(box, op)
 	screen.DrawImage(gopherImage, op)
 }
@@ -0,0 +1,154 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// HUDItem is a line of the training HUD.
+type HUDItem string
+
+const (
+	HUDAlive      HUDItem = "alive"
+	HUDGeneration HUDItem = "generation"
+	HUDFitness    HUDItem = "fitness"
+	HUDPipes      HUDItem = "pipes"
+	HUDSpecies    HUDItem = "species"
+	HUDSpeed      HUDItem = "speed"
+	HUDSparkline  HUDItem = "sparkline"
+)
+
+// HUDItems are all the HUD items in their default order.
+var HUDItems = []HUDItem{HUDAlive, HUDGeneration, HUDFitness, HUDPipes, HUDSpecies, HUDSpeed, HUDSparkline}
+
+// HUDStats is the training progress shown in the HUD.
+type HUDStats struct {
+	// Generation is the generation being played.
+	Generation int
+	Species    int
+	// BestFitness is the best fitness so far in the run.
+	BestFitness float64
+	// RecentBest is the best score of the recent generations, oldest first.
+	RecentBest []float64
+}
+
+// StatsProvider supplies the training progress to the HUD, it is called from the draw loop.
+type StatsProvider interface {
+	HUDStats() HUDStats
+}
+
+// HUD placement
+const (
+	hudX      = 10
+	hudY      = 16
+	hudRowH   = 14
+	hudSparkW = 120
+	hudSparkH = 24
+)
+
+// ParseHUD parses HUD item names separated by commas.
+func ParseHUD(spec string) ([]HUDItem, error) {
+	var items []HUDItem
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		item := HUDItem(name)
+		known := false
+		for _, it := range HUDItems {
+			known = known || it == item
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown HUD item: %s", name)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// SetHUD sets the items shown in the HUD in order.
+func (g *Game) SetHUD(items []HUDItem) {
+	g.muDraw.Lock()
+	defer g.muDraw.Unlock()
+	g.hud = items
+}
+
+// SetStatsProvider sets where the HUD takes the training progress from, nil hides the training items.
+func (g *Game) SetStatsProvider(p StatsProvider) {
+	g.muDraw.Lock()
+	defer g.muDraw.Unlock()
+	g.stats = p
+}
+
+// drawHUD draws the HUD items under each other and returns the y below them.
+func (g *Game) drawHUD(screen *ebiten.Image, sc *scene) int {
+	var stats HUDStats
+	if g.stats != nil {
+		stats = g.stats.HUDStats()
+	}
+	y := hudY
+	for _, item := range g.hud {
+		var line string
+		switch item {
+		case HUDAlive:
+			line = fmt.Sprintf("Alive: %d/%d", len(sc.gophers), sc.total)
+		case HUDGeneration:
+			if g.stats != nil {
+				line = fmt.Sprintf("Gen: %d", stats.Generation)
+			} else {
+				line = fmt.Sprintf("Game: %d", sc.resets)
+			}
+		case HUDFitness:
+			if g.stats != nil {
+				line = fmt.Sprintf("Best fitness: %.2f", stats.BestFitness)
+			}
+		case HUDPipes:
+			line = fmt.Sprintf("Pipes: %d", sc.bestPipes)
+		case HUDSpecies:
+			if g.stats != nil {
+				line = fmt.Sprintf("Species: %d", stats.Species)
+			}
+		case HUDSpeed:
+			line = g.speedString()
+		case HUDSparkline:
+			if len(stats.RecentBest) > 1 {
+				drawSparkline(screen, hudX, y, stats.RecentBest)
+				y += hudSparkH + 4
+			}
+		}
+		if line != "" {
+			ebitenutil.DebugPrintAt(screen, line, hudX, y)
+			y += hudRowH
+		}
+	}
+	return y
+}
+
+// drawSparkline draws the values as a line scaled to fit a small box with its top left at x, y.
+func drawSparkline(screen *ebiten.Image, x, y int, values []float64) {
+	vector.DrawFilledRect(screen, float32(x), float32(y), hudSparkW, hudSparkH, color.RGBA{0, 0, 0, 0x80}, false)
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+	point := func(i int) (float64, float64) {
+		px := float64(x) + float64(i)*float64(hudSparkW-1)/float64(len(values)-1)
+		py := float64(y + hudSparkH/2)
+		if hi > lo {
+			py = float64(y+hudSparkH-2) - (values[i]-lo)/(hi-lo)*float64(hudSparkH-4)
+		}
+		return px, py
+	}
+	for i := 1; i < len(values); i++ {
+		x1, y1 := point(i - 1)
+		x2, y2 := point(i)
+		DrawThickLine(screen, x1, y1, x2, y2, 1, color.RGBA{0xf0, 0xc6, 0x74, 0xff})
+	}
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("%.0f", values[len(values)-1]), x+hudSparkW+4, y+hudSparkH/2-8)
+}
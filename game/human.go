@@ -0,0 +1,59 @@
+package game
+
+// humanID is the gopher controlled by the player in human mode.
+const humanID = 0
+
+// gameOverDelay is the number of ticks the game over screen ignores input,
+// so a late jump does not restart the game right away.
+const gameOverDelay = 30
+
+// NewHumanGame creates a single gopher game driven by keyboard, mouse and touch input.
+func NewHumanGame(windowW, windowH int) *Game {
+	g := newHumanGame(windowW, windowH)
+	g.restart(1)
+	return g
+}
+
+func newHumanGame(windowW, windowH int) *Game {
+	player := &HumanController{}
+	return &Game{
+		windowW:     windowW,
+		windowH:     windowH,
+		human:       true,
+		player:      player,
+		controllers: map[int]Controller{humanID: player},
+		focusID:     leaderFocus,
+	}
+}
+
+// updateHuman advances the game by one tick according to the player input.
+func (g *Game) updateHuman() {
+	switch g.mode {
+	case ModeTitle:
+		if jumpPressed() {
+			g.mode = ModePlay
+			g.player.Press()
+		}
+	case ModePlay:
+		if pausePressed() {
+			g.mode = ModePause
+			return
+		}
+		if jumpPressed() {
+			g.player.Press()
+		}
+		g.Step()
+		if g.mode == ModeGameOver {
+			g.gameOverTicks = 0
+		}
+	case ModePause:
+		if pausePressed() || jumpPressed() {
+			g.mode = ModePlay
+		}
+	case ModeGameOver:
+		g.gameOverTicks++
+		if g.gameOverTicks > gameOverDelay && jumpPressed() {
+			g.restart(1 + g.racers)
+		}
+	}
+}
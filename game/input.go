@@ -0,0 +1,89 @@
+package game
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// jumpPressed reports whether any jump input was pressed during the current tick:
+// space or up arrow, left mouse button or a new touch.
+func jumpPressed() bool {
+	if inpututil.IsKeyJustPressed(ebiten.KeySpace) || inpututil.IsKeyJustPressed(ebiten.KeyArrowUp) {
+		return true
+	}
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		return true
+	}
+	return len(inpututil.AppendJustPressedTouchIDs(nil)) > 0
+}
+
+// pausePressed reports whether pause was toggled during the current tick.
+func pausePressed() bool {
+	return inpututil.IsKeyJustPressed(ebiten.KeyP) || inpututil.IsKeyJustPressed(ebiten.KeyEscape)
+}
+
+// brainPressed reports whether the network overlay was toggled during the current tick.
+func brainPressed() bool {
+	return inpututil.IsKeyJustPressed(ebiten.KeyN)
+}
+
+// shadersPressed reports whether the post-processing shaders were toggled during the current tick.
+func shadersPressed() bool {
+	return inpututil.IsKeyJustPressed(ebiten.KeyC)
+}
+
+// tintPressed reports whether the gopher tint mode was switched during the current tick.
+func tintPressed() bool {
+	return inpututil.IsKeyJustPressed(ebiten.KeyT)
+}
+
+// labelsPressed reports whether the gopher labels were toggled during the current tick.
+func labelsPressed() bool {
+	return inpututil.IsKeyJustPressed(ebiten.KeyL)
+}
+
+// recordPressed reports whether the recording was started or stopped during the current tick.
+func recordPressed() bool {
+	return inpututil.IsKeyJustPressed(ebiten.KeyR)
+}
+
+// stepPressed reports whether a single step was asked for during the current tick, it pauses the game.
+func stepPressed() bool {
+	return inpututil.IsKeyJustPressed(ebiten.KeyPeriod)
+}
+
+// fasterPressed reports whether the game was sped up during the current tick.
+func fasterPressed() bool {
+	return inpututil.IsKeyJustPressed(ebiten.KeyEqual) || inpututil.IsKeyJustPressed(ebiten.KeyNumpadAdd)
+}
+
+// slowerPressed reports whether the game was slowed down during the current tick.
+func slowerPressed() bool {
+	return inpututil.IsKeyJustPressed(ebiten.KeyMinus) || inpututil.IsKeyJustPressed(ebiten.KeyNumpadSubtract)
+}
+
+// renderEveryPressed reports whether the rendered generations were switched during the current tick.
+func renderEveryPressed() bool {
+	return inpututil.IsKeyJustPressed(ebiten.KeyG)
+}
+
+// followPressed reports whether the spectator mode was toggled during the current tick.
+func followPressed() bool {
+	return inpututil.IsKeyJustPressed(ebiten.KeyF)
+}
+
+// focusCycled returns the direction the focus was moved in during the current tick, 0 if it was not.
+func focusCycled() int {
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyBracketRight):
+		return 1
+	case inpututil.IsKeyJustPressed(ebiten.KeyBracketLeft):
+		return -1
+	}
+	return 0
+}
+
+// fullscreenPressed reports whether fullscreen was toggled during the current tick.
+func fullscreenPressed() bool {
+	return inpututil.IsKeyJustPressed(ebiten.KeyF11)
+}
@@ -7,16 +7,27 @@ import (
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
+// linePixel is stretched into lines, so drawing them does not allocate images.
+var linePixel = func() *ebiten.Image {
+	img := ebiten.NewImage(1, 1)
+	img.Fill(color.White)
+	return img
+}()
+
 func DrawLine(screen *ebiten.Image, x1, y1, x2, y2 int) {
-	pix := ebiten.NewImage(1, 10)
-	pix.Fill(color.RGBA{255, 0, 0, 100})
+	DrawThickLine(screen, float64(x1), float64(y1), float64(x2), float64(y2), 10, color.RGBA{255, 0, 0, 100})
+}
+
+// DrawThickLine draws a line of given width and colour centred on the segment from (x1, y1) to (x2, y2).
+func DrawThickLine(screen *ebiten.Image, x1, y1, x2, y2, width float64, clr color.Color) {
 	op := &ebiten.DrawImageOptions{}
-	dx := float64(x1) - float64(x2)
-	dy := float64(y1) - float64(y2)
+	dx := x1 - x2
+	dy := y1 - y2
 	atan := math.Atan2(dy, dx) + math.Pi
-	op.GeoM.Scale(math.Sqrt(dx*dx+dy*dy), 1)
+	op.GeoM.Translate(0, -0.5)
+	op.GeoM.Scale(math.Sqrt(dx*dx+dy*dy), width)
 	op.GeoM.Rotate(atan)
-	op.GeoM.Translate(float64(x1), float64(y1))
-	screen.DrawImage(pix, op)
-
+	op.GeoM.Translate(x1, y1)
+	op.ColorScale.ScaleWithColor(clr)
+	screen.DrawImage(linePixel, op)
 }
@@ -0,0 +1,37 @@
+//go:build display
+
+package game
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// testRunner runs the tests inside the game loop, where images can be drawn.
+type testRunner struct {
+	m    *testing.M
+	code int
+}
+
+func (r *testRunner) Update() error {
+	r.code = r.m.Run()
+	return ebiten.Termination
+}
+
+func (r *testRunner) Draw(*ebiten.Image) {}
+
+func (r *testRunner) Layout(int, int) (int, int) {
+	return WorldWidth, WorldHeight
+}
+
+// TestMain runs the tests inside the game loop. Drawing needs a display, so the drawing tests are built
+// with the display tag only and headless runs leave them out.
+func TestMain(m *testing.M) {
+	r := &testRunner{m: m}
+	if err := ebiten.RunGame(r); err != nil {
+		panic(err)
+	}
+	os.Exit(r.code)
+}
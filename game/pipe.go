@@ -36,8 +36,8 @@ type Pipe struct {
 	passed   bool
 }
 
-func NewPipe(windowW, windowH int, gap int, speed int) *Pipe {
-	topY := rand.Intn(windowH-gap-2*tileSize) + tileSize
+func NewPipe(rng *rand.Rand, windowW, windowH int, gap int, speed int) *Pipe {
+	topY := rng.Intn(windowH-gap-2*tileSize) + tileSize
 	return &Pipe{
 		x:        windowW,
 		topY:     topY,
@@ -48,26 +48,28 @@ func NewPipe(windowW, windowH int, gap int, speed int) *Pipe {
 	}
 }
 
+// Draw draws the pipe, it only reads the pipe, so pipes copied out of the simulation can be drawn.
 func (p *Pipe) Draw(screen *ebiten.Image) {
 	// top part
 	op := &ebiten.DrawImageOptions{}
 	op.GeoM.Scale(1, -1)
-	topShaftH := p.topY - p.topImg.Bounds().Dy()
-	topShaftTiling := toTiling(p.shaftImg, p.Width(), topShaftH)
-	op.GeoM.Translate(float64(p.x), float64(topShaftH))
-	screen.DrawImage(topShaftTiling, op)
+	op.GeoM.Translate(float64(p.x), float64(p.topY-p.topImg.Bounds().Dy()))
+	drawShaft(screen, shaftTiling(p.topY-p.topImg.Bounds().Dy()), op)
 	op.GeoM.Translate(0, tileSize)
 	screen.DrawImage(p.topImg, op)
 
 	//bottom part
-	deltaY := p.topY + p.gap
 	op.GeoM.Reset()
-	botShaftH := screen.Bounds().Dy() - deltaY - tileSize
-	botShaftTiling := toTiling(p.shaftImg, p.Width(), botShaftH)
-	op.GeoM.Translate(float64(p.x), float64(deltaY))
+	op.GeoM.Translate(float64(p.x), float64(p.PosBotY()))
 	screen.DrawImage(p.topImg, op)
 	op.GeoM.Translate(0, tileSize)
-	screen.DrawImage(botShaftTiling, op)
+	drawShaft(screen, shaftTiling(screen.Bounds().Dy()-p.PosBotY()-tileSize), op)
+}
+
+func drawShaft(screen, shaft *ebiten.Image, op *ebiten.DrawImageOptions) {
+	if shaft != nil {
+		screen.DrawImage(shaft, op)
+	}
 }
 
 func (p *Pipe) Collide(gopher *Gopher) bool {
@@ -114,6 +116,30 @@ func (p *Pipe) PosBotY() int {
 	return p.topY + p.gap
 }
 
+// shaftColumn is the shaft tile repeated down a column, every pipe shaft is a sub-image of it,
+// so drawing pipes does not allocate images. Shafts are cut once for every height and only used by Draw.
+var (
+	shaftColumn *ebiten.Image
+	shafts      = make(map[int]*ebiten.Image)
+)
+
+// shaftTiling returns a shaft of the given height, nil if there is nothing to draw.
+// The shaft column is replaced by a taller one when needed, the shafts cut from the old one keep it alive.
+func shaftTiling(height int) *ebiten.Image {
+	if height < 1 {
+		return nil
+	}
+	if shaft, ok := shafts[height]; ok {
+		return shaft
+	}
+	if shaftColumn == nil || shaftColumn.Bounds().Dy() < height {
+		shaftColumn = toTiling(shaftImg, PipeWidth, height)
+	}
+	shaft := shaftColumn.SubImage(image.Rect(0, 0, PipeWidth, height)).(*ebiten.Image)
+	shafts[height] = shaft
+	return shaft
+}
+
 func toTiling(img *ebiten.Image, targetWidth, targetHeight int) *ebiten.Image {
 	if targetHeight < 1 || targetWidth < 1 {
 		return ebiten.NewImage(1, 1)
@@ -0,0 +1,26 @@
+//go:build display
+
+package game
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func BenchmarkPipeDraw(b *testing.B) {
+	screen := ebiten.NewImage(WorldWidth, WorldHeight)
+	rng := rand.New(rand.NewSource(1))
+	pipes := make([]*Pipe, 4)
+	for i := range pipes {
+		pipes[i] = NewPipe(rng, WorldWidth, WorldHeight, pipeGap, scrollSpeed)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, pipe := range pipes {
+			pipe.Draw(screen)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package game
+
+import (
+	"embed"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+//go:embed shaders/*.kage
+var shaderFS embed.FS
+
+// shaderDefaults are the shaders of the post-processing chain with their default uniforms.
+var shaderDefaults = map[string]map[string]any{
+	"crt":       {},
+	"scanlines": {"Intensity": float32(0.25)},
+	"vignette":  {"Strength": float32(0.6)},
+	"grading": {
+		"Brightness": float32(0),
+		"Contrast":   float32(1.1),
+		"Saturation": float32(1.2),
+		"Tint":       []float32{1, 0.97, 0.9},
+	},
+}
+
+// ShaderPass is a single shader of the post-processing chain.
+type ShaderPass struct {
+	Name string
+	// Uniforms override the shader defaults.
+	Uniforms map[string]any
+}
+
+// ParseShaderChain parses passes separated by commas, each one a shader name optionally followed by a colon
+// and uniforms separated by semicolons, vector components separated by spaces:
+//
+//	grading:Saturation=1.4;Tint=1 0.9 0.8,scanlines,vignette:Strength=0.8,crt
+func ParseShaderChain(spec string) ([]ShaderPass, error) {
+	var chain []ShaderPass
+	for _, passSpec := range strings.Split(spec, ",") {
+		passSpec = strings.TrimSpace(passSpec)
+		if passSpec == "" {
+			continue
+		}
+		name, params, _ := strings.Cut(passSpec, ":")
+		pass := ShaderPass{Name: name, Uniforms: map[string]any{}}
+		for _, param := range strings.Split(params, ";") {
+			if param == "" {
+				continue
+			}
+			key, value, ok := strings.Cut(param, "=")
+			if !ok {
+				return nil, fmt.Errorf("shader %s: uniform '%s' has no value", name, param)
+			}
+			var floats []float32
+			for _, field := range strings.Fields(value) {
+				f, err := strconv.ParseFloat(field, 32)
+				if err != nil {
+					return nil, fmt.Errorf("shader %s: uniform %s: %w", name, key, err)
+				}
+				floats = append(floats, float32(f))
+			}
+			if len(floats) == 1 {
+				pass.Uniforms[key] = floats[0]
+			} else {
+				pass.Uniforms[key] = floats
+			}
+		}
+		chain = append(chain, pass)
+	}
+	return chain, nil
+}
+
+type postPass struct {
+	shader   *ebiten.Shader
+	uniforms map[string]any
+}
+
+// PostProcess applies an ordered chain of Kage shaders to the rendered frame.
+type PostProcess struct {
+	passes  []postPass
+	enabled bool
+	// buffers are the offscreen images the passes render into in turns, the first one holds the scene.
+	buffers [2]*ebiten.Image
+}
+
+// NewPostProcess compiles the shaders of the chain, the post-processing starts enabled or not.
+func NewPostProcess(chain []ShaderPass, enabled bool) (*PostProcess, error) {
+	pp := &PostProcess{enabled: enabled}
+	for _, pass := range chain {
+		defaults, ok := shaderDefaults[pass.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown shader: %s", pass.Name)
+		}
+		src, err := shaderFS.ReadFile("shaders/" + pass.Name + ".kage")
+		if err != nil {
+			return nil, err
+		}
+		shader, err := ebiten.NewShader(src)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile shader %s: %w", pass.Name, err)
+		}
+		uniforms := make(map[string]any, len(defaults))
+		for k, v := range defaults {
+			uniforms[k] = v
+		}
+		for k, v := range pass.Uniforms {
+			if _, ok = defaults[k]; !ok {
+				return nil, fmt.Errorf("shader %s has no uniform %s", pass.Name, k)
+			}
+			uniforms[k] = v
+		}
+		pp.passes = append(pp.passes, postPass{shader: shader, uniforms: uniforms})
+	}
+	return pp, nil
+}
+
+// Toggle enables or disables the post-processing.
+func (pp *PostProcess) Toggle() {
+	pp.enabled = !pp.enabled
+}
+
+func (pp *PostProcess) active() bool {
+	return pp != nil && pp.enabled && len(pp.passes) > 0
+}
+
+// target returns the offscreen image to render the scene into.
+func (pp *PostProcess) target(w, h int) *ebiten.Image {
+	for i, buf := range pp.buffers {
+		if buf == nil || buf.Bounds().Dx() != w || buf.Bounds().Dy() != h {
+			if buf != nil {
+				buf.Dispose()
+			}
+			pp.buffers[i] = ebiten.NewImage(w, h)
+		}
+	}
+	pp.buffers[0].Clear()
+	return pp.buffers[0]
+}
+
+// apply runs the chain on the scene rendered into the target, the last pass draws onto the screen.
+func (pp *PostProcess) apply(screen *ebiten.Image) {
+	src := 0
+	for i, pass := range pp.passes {
+		dst := screen
+		if i < len(pp.passes)-1 {
+			dst = pp.buffers[1-src]
+			dst.Clear()
+		}
+		op := &ebiten.DrawRectShaderOptions{Uniforms: pass.uniforms}
+		op.Images[0] = pp.buffers[src]
+		b := pp.buffers[src].Bounds()
+		dst.DrawRectShader(b.Dx(), b.Dy(), pass.shader, op)
+		src = 1 - src
+	}
+}
+
+// SetPostProcess sets the post-processing chain applied to every frame, nil disables it.
+func (g *Game) SetPostProcess(pp *PostProcess) {
+	g.muDraw.Lock()
+	defer g.muDraw.Unlock()
+	g.post = pp
+}
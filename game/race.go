@@ -0,0 +1,64 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+)
+
+// racerTint is the colour of the controlled gophers in the race.
+var racerTint = color.RGBA{0xff, 0x80, 0x80, 0xff}
+
+// NewRaceGame creates a game where the player races against gophers driven by the racers on the same course.
+// The course is generated from seed, so every restart replays it.
+func NewRaceGame(windowW, windowH int, seed int64, racers ...Controller) *Game {
+	g := newHumanGame(windowW, windowH)
+	g.seed = seed
+	g.racers = len(racers)
+	for i, c := range racers {
+		g.controllers[humanID+1+i] = c
+	}
+	g.restart(1 + g.racers)
+	return g
+}
+
+// raceLost reports whether the player is out of the race.
+func (g *Game) raceLost() bool {
+	if g.racers == 0 {
+		return false
+	}
+	_, alive := g.gophers[humanID]
+	return !alive
+}
+
+// raceResults returns the results screen lines comparing pipes passed by the player and the racers.
+func (g *Game) raceResults() []string {
+	ids := make([]int, 0, len(g.records))
+	for id := range g.records {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	var best Record
+	lines := []string{"", "PIPES PASSED"}
+	for _, id := range ids {
+		rec := g.records[id]
+		name := "YOU"
+		if id != humanID {
+			name = fmt.Sprintf("AI %d", id)
+			if rec.Pipes > best.Pipes || rec.Pipes == best.Pipes && rec.Steps > best.Steps {
+				best = *rec
+			}
+		}
+		lines = append(lines, fmt.Sprintf("%-5s %4d", name, rec.Pipes))
+	}
+	lines = append(lines, "")
+	// on equal pipes the one who flew longer wins
+	you := g.records[humanID]
+	if you.Pipes > best.Pipes || you.Pipes == best.Pipes && you.Steps > best.Steps {
+		lines = append(lines, "YOU WIN!")
+	} else {
+		lines = append(lines, "AI WINS!")
+	}
+	return lines
+}
@@ -0,0 +1,86 @@
+package game
+
+import "math"
+
+// Record is the outcome of a single gopher in the current game.
+type Record struct {
+	ID int
+	// Steps is the number of steps the gopher survived.
+	Steps int
+	// Pipes is the number of pipes the gopher passed.
+	Pipes int
+	// Jumps is the number of jumps the gopher made.
+	Jumps int
+	// GapDistance is the vertical distance from the gopher to the centre of the gap of the closest pipe ahead
+	// at its last step, as a fraction of the window height.
+	GapDistance float64
+	// PassOffsets are the vertical offsets from the gap centre to the gopher at every passed pipe,
+	// as fractions of the window height, positive below the centre.
+	PassOffsets []float64
+	// LastY is the centre of the gopher at its last step as a fraction of the window height.
+	LastY float64
+	Alive bool
+}
+
+// PipeSteps returns the number of steps a gopher has to survive to pass the given number of pipes
+// in a window of the given width.
+func PipeSteps(windowW, pipes int) int {
+	if pipes < 1 {
+		return 0
+	}
+	// the first pipe spawns at the right edge and scrolls until its tail is behind the gophers
+	firstPass := (windowW+PipeWidth-gophersX)/scrollSpeed + 1
+	return pipeSpawnDelay*(pipes-1) + firstPass + 1
+}
+
+// Records returns a copy of the records of all gophers in the current game, alive or not.
+func (g *Game) Records() map[int]Record {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	res := make(map[int]Record, len(g.records))
+	for id, rec := range g.records {
+		cp := *rec
+		cp.PassOffsets = append([]float64(nil), rec.PassOffsets...)
+		res[id] = cp
+	}
+	return res
+}
+
+// recordStep accounts one survived step for every alive gopher.
+func (g *Game) recordStep() {
+	top, bot := g.closestPipeYs()
+	gapCentre := (top + bot) / 2
+	for id, gopher := range g.gophers {
+		rec := g.records[id]
+		rec.Steps++
+		rec.LastY = g.gopherCentreY(gopher)
+		rec.GapDistance = math.Abs(rec.LastY - gapCentre)
+	}
+}
+
+// recordPass accounts the passed pipe for every alive gopher.
+func (g *Game) recordPass(pipe *Pipe) {
+	gapCentre := float64(pipe.PosTopY()+pipe.PosBotY()) / 2 / float64(g.windowH)
+	for id, gopher := range g.gophers {
+		rec := g.records[id]
+		rec.Pipes++
+		rec.PassOffsets = append(rec.PassOffsets, g.gopherCentreY(gopher)-gapCentre)
+	}
+}
+
+// gopherCentreY returns the centre of the gopher as a fraction of the window height.
+func (g *Game) gopherCentreY(gopher *Gopher) float64 {
+	return float64(gopher.PosY()+gopher.Height()/2) / float64(g.windowH)
+}
+
+// jump makes the gopher jump and accounts it.
+func (g *Game) jump(gopher *Gopher) {
+	gopher.Jump()
+	g.records[gopher.ID].Jumps++
+}
+
+// kill removes the gopher from the game and freezes its record.
+func (g *Game) kill(gopher *Gopher) {
+	delete(g.gophers, gopher.ID)
+	g.records[gopher.ID].Alive = false
+}
@@ -0,0 +1,147 @@
+package game
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// scene is a copy of everything drawn of the game, so the game can be drawn while the simulation goes on.
+type scene struct {
+	mode    GameMode
+	score   int
+	resets  int
+	gophers []Gopher
+	ghosts  []ghostView
+	pipes   []Pipe
+	base    Base
+	// total is the number of gophers in the game, alive or not, bestPipes the most pipes passed by one of them.
+	total     int
+	bestPipes int
+	// brain is the brain of the focused gopher, nil if it has none.
+	brain       Brain
+	raceResults []string
+	// the spectator mode: the focused gopher, whether it is the leader and its trail
+	follow        bool
+	focusedID     int
+	leaderFocused bool
+	trail         []trailPoint
+}
+
+// fillScene copies the game into the scene reusing its slices, g.mu must be held.
+func (g *Game) fillScene(sc *scene) {
+	sc.mode = g.mode
+	sc.score = g.score
+	sc.resets = g.resetsNum
+	sc.gophers = sc.gophers[:0]
+	for _, gopher := range g.gophers {
+		sc.gophers = append(sc.gophers, *gopher)
+	}
+	sc.ghosts = sc.ghosts[:0]
+	for _, run := range g.ghostRuns {
+		sc.ghosts = append(sc.ghosts, ghostView{Gopher: *run.gopher, name: run.ghost.Name})
+	}
+	sc.pipes = sc.pipes[:0]
+	for _, pipe := range g.pipes {
+		sc.pipes = append(sc.pipes, *pipe)
+	}
+	sc.base = *g.base
+	sc.total = len(g.records)
+	sc.bestPipes = 0
+	for _, rec := range g.records {
+		sc.bestPipes = max(sc.bestPipes, rec.Pipes)
+	}
+	sc.brain = g.focusedBrain()
+	sc.follow = g.follow
+	sc.focusedID = g.focusedID
+	_, chosenAlive := g.gophers[g.focusID]
+	sc.leaderFocused = g.focusID == leaderFocus || !chosenAlive
+	sc.trail = append(sc.trail[:0], g.trail...)
+	sc.raceResults = nil
+	if g.mode == ModeGameOver && g.racers > 0 {
+		sc.raceResults = g.raceResults()
+	}
+}
+
+// sceneFresh marks the shared scene as not taken by the renderer yet, the rest of the state is its index.
+const sceneFresh = 4
+
+// sceneBuffer hands scenes from the simulation to the renderer without locks. Each side owns a scene,
+// the simulation fills its back scene and swaps it with the shared one, the renderer swaps its front scene
+// with the shared one when a fresh one is there. Scenes are reused, so publishing does not allocate.
+type sceneBuffer struct {
+	scenes [3]scene
+	shared atomic.Uint32
+	back   int
+	front  int
+}
+
+func newSceneBuffer() *sceneBuffer {
+	return &sceneBuffer{back: 1, front: 2}
+}
+
+// wanted reports whether the renderer took the last published scene, so a new one is worth filling.
+func (b *sceneBuffer) wanted() bool {
+	return b.shared.Load()&sceneFresh == 0
+}
+
+// publish shares the back scene once it is filled.
+func (b *sceneBuffer) publish() {
+	b.back = int(b.shared.Swap(uint32(b.back)|sceneFresh) &^ sceneFresh)
+}
+
+// take returns the latest published scene, it stays valid until the next take. nil if nothing was published.
+func (b *sceneBuffer) take() *scene {
+	if b.shared.Load()&sceneFresh != 0 {
+		b.front = int(b.shared.Swap(uint32(b.front)) &^ sceneFresh)
+	}
+	sc := &b.scenes[b.front]
+	if sc.base.baseImg == nil {
+		return nil
+	}
+	return sc
+}
+
+// simulationIdle is how long the detached simulation waits when paused or between games.
+const simulationIdle = time.Millisecond
+
+// Detach moves the simulation to its own goroutine stepping the game as fast as it can until ctx is done,
+// pausing and single steps still apply. The window only draws the latest scene, so rendering never slows
+// the game down. Once ctx is done the window keeps drawing the last scene.
+func (g *Game) Detach(ctx context.Context) {
+	g.muDraw.Lock()
+	defer g.muDraw.Unlock()
+	if g.scenes != nil {
+		return
+	}
+	g.scenes = newSceneBuffer()
+	go g.simulate(ctx)
+}
+
+func (g *Game) simulate(ctx context.Context) {
+	idle := time.NewTicker(simulationIdle)
+	defer idle.Stop()
+	for ctx.Err() == nil {
+		if g.simPaused.Load() && !g.simStep.CompareAndSwap(true, false) || g.over() {
+			g.publishScene()
+			select {
+			case <-ctx.Done():
+			case <-idle.C:
+			}
+			continue
+		}
+		g.Step()
+		g.publishScene()
+	}
+}
+
+// publishScene hands the game to the renderer unless the last scene was not drawn yet.
+func (g *Game) publishScene() {
+	if !g.scenes.wanted() {
+		return
+	}
+	g.mu.Lock()
+	g.fillScene(&g.scenes.scenes[g.scenes.back])
+	g.mu.Unlock()
+	g.scenes.publish()
+}
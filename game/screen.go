@@ -0,0 +1,71 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// The course is simulated in a world of a fixed size whatever the window resolution,
+// so pipe spacing, physics and observations, which are fractions of the world, do not depend on it.
+const (
+	WorldWidth  = 640
+	WorldHeight = 480
+)
+
+// letterboxColor fills the screen around the world when their aspect ratios differ.
+var letterboxColor = color.Black
+
+// SetResolution sets the logical resolution the drawn world is scaled to, 0x0 follows the size of the window.
+// Everything is still drawn at the world size, only the finished picture is scaled. Call it before the game runs.
+func (g *Game) SetResolution(w, h int) error {
+	if w < 0 || h < 0 || (w == 0) != (h == 0) {
+		return fmt.Errorf("invalid resolution %dx%d", w, h)
+	}
+	g.resW, g.resH, g.resSet = w, h, true
+	return nil
+}
+
+// Layout returns the logical resolution, the world size unless another resolution was set.
+func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
+	switch {
+	case !g.resSet:
+		return g.windowW, g.windowH
+	case g.resW == 0:
+		scale := ebiten.DeviceScaleFactor()
+		return int(float64(outsideWidth) * scale), int(float64(outsideHeight) * scale)
+	default:
+		return g.resW, g.resH
+	}
+}
+
+// worldTarget returns the image the world is drawn onto: the screen itself if it has the world size,
+// otherwise an offscreen image scaled onto the screen by present.
+func (g *Game) worldTarget(screen *ebiten.Image) *ebiten.Image {
+	if screen.Bounds().Dx() == g.windowW && screen.Bounds().Dy() == g.windowH {
+		return screen
+	}
+	if g.worldBuf == nil || g.worldBuf.Bounds().Dx() != g.windowW || g.worldBuf.Bounds().Dy() != g.windowH {
+		g.worldBuf = ebiten.NewImage(g.windowW, g.windowH)
+	}
+	g.worldBuf.Clear()
+	return g.worldBuf
+}
+
+// present scales the drawn world to fit the screen keeping its aspect ratio, centred between letterbox bars.
+func (g *Game) present(screen, world *ebiten.Image) {
+	sw, sh := float64(screen.Bounds().Dx()), float64(screen.Bounds().Dy())
+	scale := min(sw/float64(g.windowW), sh/float64(g.windowH))
+	screen.Fill(letterboxColor)
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(scale, scale)
+	op.GeoM.Translate((sw-scale*float64(g.windowW))/2, (sh-scale*float64(g.windowH))/2)
+	op.Filter = ebiten.FilterLinear
+	screen.DrawImage(world, op)
+}
+
+// ToggleFullscreen switches between the window and fullscreen.
+func ToggleFullscreen() {
+	ebiten.SetFullscreen(!ebiten.IsFullscreen())
+}
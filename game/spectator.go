@@ -0,0 +1,165 @@
+package game
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"sort"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// leaderFocus follows the best alive gopher instead of a chosen one.
+const leaderFocus = -1
+
+// spectator placement
+const (
+	trailLen  = 60
+	pipW      = 160
+	pipH      = 120
+	pipZoom   = 2
+	pipMargin = 10
+)
+
+var (
+	focusColor = color.RGBA{0xff, 0xd0, 0x40, 0xff}
+	trailColor = color.RGBA{0xff, 0xd0, 0x40, 0xa0}
+)
+
+// trailPoint is a past centre of the focused gopher.
+type trailPoint struct {
+	x, y float64
+}
+
+// ToggleFollow turns the spectator mode on or off: the focused gopher is outlined, leaves a trail
+// and is zoomed on in a picture-in-picture panel.
+func (g *Game) ToggleFollow() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.follow = !g.follow
+}
+
+// CycleFocus moves the focus by dir through the alive gophers in the order of their ids.
+// Moving past either end goes back to following the leader.
+func (g *Game) CycleFocus(dir int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.follow = true
+	// the leader is first in the cycle
+	ids := []int{leaderFocus}
+	for id := range g.gophers {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	i := sort.SearchInts(ids, g.focusID)
+	if i == len(ids) || ids[i] != g.focusID {
+		i = 0
+	}
+	g.focusID = ids[(i+dir+len(ids))%len(ids)]
+	g.updateFocus()
+}
+
+// leader returns the id of the best alive gopher: the most pipes passed, then the closest to the gap centre.
+func (g *Game) leader() (int, bool) {
+	best, found := 0, false
+	for id := range g.gophers {
+		if !found {
+			best, found = id, true
+			continue
+		}
+		rec, bestRec := g.records[id], g.records[best]
+		if rec.Pipes > bestRec.Pipes || rec.Pipes == bestRec.Pipes &&
+			(rec.GapDistance < bestRec.GapDistance || rec.GapDistance == bestRec.GapDistance && id < best) {
+			best = id
+		}
+	}
+	return best, found
+}
+
+// updateFocus picks the focused gopher, the leader when the chosen one died, and extends its trail.
+// The trail starts anew when the focus moves to another gopher. g.mu must be held.
+func (g *Game) updateFocus() {
+	id, ok := g.focusID, false
+	if id != leaderFocus {
+		_, ok = g.gophers[id]
+	}
+	if !ok {
+		id, ok = g.leader()
+	}
+	if !ok {
+		g.trail = g.trail[:0]
+		return
+	}
+	if id != g.focusedID {
+		g.focusedID = id
+		g.trail = g.trail[:0]
+	}
+	gopher := g.gophers[id]
+	g.trail = append(g.trail, trailPoint{
+		x: gopher.x + float64(gopher.Width())/2,
+		y: gopher.y + float64(gopher.Height())/2,
+	})
+	if len(g.trail) > trailLen {
+		g.trail = g.trail[:copy(g.trail, g.trail[len(g.trail)-trailLen:])]
+	}
+}
+
+// focusedGopher returns the focused gopher of the scene, nil if there is none or the spectator mode is off.
+func (sc *scene) focusedGopher() *Gopher {
+	if !sc.follow {
+		return nil
+	}
+	for i := range sc.gophers {
+		if sc.gophers[i].ID == sc.focusedID {
+			return &sc.gophers[i]
+		}
+	}
+	return nil
+}
+
+// drawFocus outlines the focused gopher and draws its trail. The gopher only moves vertically,
+// so every trail point is shifted left by the distance the course scrolled since.
+func drawFocus(screen *ebiten.Image, sc *scene, gopher *Gopher) {
+	n := len(sc.trail)
+	shift := func(i int) float64 {
+		return sc.trail[i].x - float64((n-1-i)*scrollSpeed)
+	}
+	for i := 1; i < n; i++ {
+		DrawThickLine(screen, shift(i-1), sc.trail[i-1].y, shift(i), sc.trail[i].y, 1+2*float64(i)/float64(n), trailColor)
+	}
+	vector.StrokeRect(screen, float32(gopher.PosX()-2), float32(gopher.PosY()-2),
+		float32(gopher.Width()+4), float32(gopher.Height()+4), 2, focusColor, false)
+}
+
+// drawPictureInPicture zooms on the focused gopher in a panel at the bottom right corner.
+// The region is copied to the panel buffer first, as an image cannot be drawn onto itself.
+func (g *Game) drawPictureInPicture(screen *ebiten.Image, gopher *Gopher, leader bool) {
+	const srcW, srcH = pipW / pipZoom, pipH / pipZoom
+	b := screen.Bounds()
+	cx := gopher.PosX() + gopher.Width()/2
+	cy := gopher.PosY() + gopher.Height()/2
+	x0 := min(max(cx-srcW/2, b.Min.X), b.Max.X-srcW)
+	y0 := min(max(cy-srcH/2, b.Min.Y), b.Max.Y-srcH)
+	if g.pipBuf == nil {
+		g.pipBuf = ebiten.NewImage(srcW, srcH)
+	}
+	g.pipBuf.Clear()
+	g.pipBuf.DrawImage(screen.SubImage(image.Rect(x0, y0, x0+srcW, y0+srcH)).(*ebiten.Image), nil)
+
+	px, py := b.Dx()-pipW-pipMargin, b.Dy()-pipH-pipMargin-tileSize
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(pipZoom, pipZoom)
+	op.GeoM.Translate(float64(px), float64(py))
+	screen.DrawImage(g.pipBuf, op)
+	vector.StrokeRect(screen, float32(px), float32(py), pipW, pipH, 2, focusColor, false)
+	name := fmt.Sprintf("gopher %d", gopher.ID)
+	if label := g.infos[gopher.ID].Label; label != "" {
+		name = label
+	}
+	if leader {
+		name = "leader " + name
+	}
+	ebitenutil.DebugPrintAt(screen, name, px+4, py+2)
+}
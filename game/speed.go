@@ -0,0 +1,127 @@
+package game
+
+import (
+	"fmt"
+	"time"
+)
+
+// baseStepsPerTick is the pace of the game at 1x, the one it has always been played at.
+const baseStepsPerTick = 2
+
+// simulation speed limits as multiples of the base pace
+const (
+	minSpeed     = 0.25
+	maxSpeed     = 64
+	defaultSpeed = 1
+)
+
+// fastForwardBudget is the time spent stepping a generation that is not rendered on every tick.
+const fastForwardBudget = 12 * time.Millisecond
+
+// renderEverySteps are the choices the key cycles through for rendering every Nth generation only.
+var renderEverySteps = []int{1, 2, 5, 10, 50}
+
+// SetSpeed sets the speed as a multiple of the base pace, fractions slow the game down.
+func (g *Game) SetSpeed(speed float64) error {
+	if speed < minSpeed || speed > maxSpeed {
+		return fmt.Errorf("speed must be from %gx to %gx", float64(minSpeed), float64(maxSpeed))
+	}
+	g.simSpeed = speed
+	return nil
+}
+
+// SetPaused pauses or resumes the simulation.
+func (g *Game) SetPaused(paused bool) {
+	g.simPaused.Store(paused)
+}
+
+// SetRenderEvery renders only every Nth generation, the others are fast-forwarded. 1 renders all of them.
+func (g *Game) SetRenderEvery(n int) error {
+	if n < 1 {
+		return fmt.Errorf("render every must be positive: %d", n)
+	}
+	g.renderEvery = n
+	return nil
+}
+
+// updateSpeed applies the speed keys.
+func (g *Game) updateSpeed() {
+	if pausePressed() {
+		g.simPaused.Store(!g.simPaused.Load())
+	}
+	if stepPressed() {
+		g.simPaused.Store(true)
+		g.simStep.Store(true)
+	}
+	if fasterPressed() {
+		g.simSpeed = min(g.simSpeed*2, maxSpeed)
+	}
+	if slowerPressed() {
+		g.simSpeed = max(g.simSpeed/2, minSpeed)
+	}
+	if renderEveryPressed() {
+		next := renderEverySteps[0]
+		for _, n := range renderEverySteps {
+			if n > g.renderEvery {
+				next = n
+				break
+			}
+		}
+		g.renderEvery = next
+	}
+}
+
+// advance makes the steps due this tick: a single step when paused and asked for one, as many as fit into
+// the time budget when the generation is fast-forwarded, the speed worth of steps otherwise.
+// Nothing is stepped while the game is over and waits for a restart.
+func (g *Game) advance() {
+	if g.over() {
+		g.simBudget = 0
+		return
+	}
+	if g.simPaused.Load() {
+		if g.simStep.CompareAndSwap(true, false) {
+			g.Step()
+		}
+		return
+	}
+	if g.fastForward() {
+		deadline := time.Now().Add(fastForwardBudget)
+		for time.Now().Before(deadline) && !g.over() {
+			g.Step()
+		}
+		return
+	}
+	g.simBudget += g.simSpeed * baseStepsPerTick
+	for ; g.simBudget >= 1 && !g.over(); g.simBudget-- {
+		g.Step()
+	}
+}
+
+// generation returns the generation reported by the stats provider, the number of games without one.
+func (g *Game) generation() int {
+	if g.stats != nil {
+		return g.stats.HUDStats().Generation
+	}
+	return g.resetsNum
+}
+
+// fastForward reports whether the current generation is skipped by the rendering.
+func (g *Game) fastForward() bool {
+	return g.renderEvery > 1 && g.generation()%g.renderEvery != 0
+}
+
+// speedString describes the speed mode for the HUD.
+func (g *Game) speedString() string {
+	s := fmt.Sprintf("Speed: %gx", g.simSpeed)
+	if g.scenes != nil {
+		s = "Speed: max"
+	}
+	if g.simPaused.Load() {
+		s += " paused"
+	}
+	if g.renderEvery > 1 {
+		s += fmt.Sprintf(", render gen %% %d", g.renderEvery)
+	}
+	return s
+}
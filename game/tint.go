@@ -0,0 +1,155 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"sort"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// TintMode selects how gophers are coloured.
+type TintMode int
+
+const (
+	// TintGroup colours gophers by their group, gophers without info are left alone.
+	TintGroup TintMode = iota
+	// TintGopher gives every gopher its own colour.
+	TintGopher
+	TintNone
+)
+
+func (m TintMode) String() string {
+	switch m {
+	case TintGroup:
+		return "group"
+	case TintGopher:
+		return "gopher"
+	default:
+		return "none"
+	}
+}
+
+// GopherInfo describes a gopher for the display, it is supplied by whoever drives the gopher.
+type GopherInfo struct {
+	// Group is shared by gophers drawn in the same colour, e.g. the NEAT species of the organism.
+	Group int
+	// Label is drawn next to the gopher when labels are shown, the gopher id is drawn if it is empty.
+	Label string
+}
+
+// legend placement
+const (
+	legendRows   = 12
+	legendRowH   = 14
+	legendSwatch = 10
+	legendW      = 100
+)
+
+// SetGopherInfo sets the info of the gophers by gopher id, replacing the previous one.
+// Infos are kept between restarts like controllers.
+func (g *Game) SetGopherInfo(infos map[int]GopherInfo) {
+	g.muDraw.Lock()
+	defer g.muDraw.Unlock()
+	g.infos = infos
+}
+
+// CycleTint switches to the next tint mode.
+func (g *Game) CycleTint() {
+	g.muDraw.Lock()
+	defer g.muDraw.Unlock()
+	g.tintMode = (g.tintMode + 1) % (TintNone + 1)
+}
+
+// ToggleLabels shows or hides the gopher labels.
+func (g *Game) ToggleLabels() {
+	g.muDraw.Lock()
+	defer g.muDraw.Unlock()
+	g.showLabels = !g.showLabels
+}
+
+// gopherTint returns the colour of the gopher in the current tint mode, nil for the original image.
+func (g *Game) gopherTint(gopher *Gopher) color.Color {
+	switch g.tintMode {
+	case TintGopher:
+		return paletteColor(gopher.ID)
+	case TintGroup:
+		if info, ok := g.infos[gopher.ID]; ok {
+			return paletteColor(info.Group)
+		}
+	}
+	return nil
+}
+
+// drawLabel draws the label of the gopher above its head.
+func (g *Game) drawLabel(screen *ebiten.Image, gopher *Gopher) {
+	label := g.infos[gopher.ID].Label
+	if label == "" {
+		label = fmt.Sprint(gopher.ID)
+	}
+	ebitenutil.DebugPrintAt(screen, label, gopher.PosX(), gopher.PosY()-16)
+}
+
+// drawLegend lists the groups of the alive gophers with their colours and the number of gophers alive.
+func (g *Game) drawLegend(screen *ebiten.Image, sc *scene) {
+	if g.tintMode != TintGroup || len(g.infos) == 0 {
+		return
+	}
+	alive := make(map[int]int)
+	for _, gopher := range sc.gophers {
+		if info, ok := g.infos[gopher.ID]; ok {
+			alive[info.Group]++
+		}
+	}
+	groups := make([]int, 0, len(alive))
+	for group := range alive {
+		groups = append(groups, group)
+	}
+	sort.Ints(groups)
+
+	x, y := g.windowW-legendW, fontSize+8
+	for i, group := range groups {
+		if i == legendRows {
+			ebitenutil.DebugPrintAt(screen, fmt.Sprintf("+%d more", len(groups)-legendRows), x, y)
+			break
+		}
+		vector.DrawFilledRect(screen, float32(x), float32(y+3), legendSwatch, legendSwatch, paletteColor(group), false)
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("sp %d: %d", group, alive[group]), x+legendSwatch+4, y)
+		y += legendRowH
+	}
+}
+
+// paletteColor returns a distinct bright colour for every index, hues are a golden angle apart.
+func paletteColor(i int) color.Color {
+	hue := math.Mod(float64(i)*137.508, 360)
+	if hue < 0 {
+		hue += 360
+	}
+	return hsv(hue, 0.6, 1)
+}
+
+// hsv converts hue in degrees, saturation and value in [0, 1] to an opaque colour.
+func hsv(h, s, v float64) color.RGBA {
+	c := v * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := v - c
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g = c, x
+	case h < 120:
+		r, g = x, c
+	case h < 180:
+		g, b = c, x
+	case h < 240:
+		g, b = x, c
+	case h < 300:
+		r, b = x, c
+	default:
+		r, b = c, x
+	}
+	return color.RGBA{uint8(255 * (r + m)), uint8(255 * (g + m)), uint8(255 * (b + m)), 255}
+}
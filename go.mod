@@ -4,9 +4,9 @@ go 1.21.3
 
 require (
 	github.com/hajimehoshi/ebiten/v2 v2.6.2
-	github.com/robotn/gohook v0.41.0
 	github.com/yaricom/goNEAT/v4 v4.0.2
 	golang.org/x/image v0.14.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -15,7 +15,6 @@ require (
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/sbinet/npyio v0.8.0 // indirect
 	github.com/spf13/cast v1.5.1 // indirect
-	github.com/vcaesar/keycode v0.10.1 // indirect
 	golang.org/x/exp v0.0.0-20231110203233-9a3e6036ecaa // indirect
 	golang.org/x/exp/shiny v0.0.0-20231110203233-9a3e6036ecaa // indirect
 	golang.org/x/mobile v0.0.0-20231108233038-35478a0c49da // indirect
@@ -24,5 +23,4 @@ require (
 	golang.org/x/text v0.14.0 // indirect
 	gonum.org/v1/gonum v0.14.0 // indirect
 	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,89 @@
+package neapy
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+const (
+	AggregateMean        = "mean"
+	AggregateMin         = "min"
+	AggregateTrimmedMean = "trimmed_mean"
+	AggregateCVaR        = "cvar"
+)
+
+// Aggregate combines fitness of a single organism over several courses into one value.
+type Aggregate func(fitness []float64) float64
+
+// NewAggregate returns the named statistic. trim is the share dropped on each side by trimmed mean,
+// alpha is the share of the worst values averaged by CVaR.
+func NewAggregate(name string, trim, alpha float64) (Aggregate, error) {
+	switch name {
+	case AggregateMean:
+		return mean, nil
+	case AggregateMin:
+		return minimum, nil
+	case AggregateTrimmedMean:
+		if trim < 0 || trim >= 0.5 {
+			return nil, fmt.Errorf("trim must be in [0, 0.5), got %f", trim)
+		}
+		return func(fitness []float64) float64 {
+			sorted := sortedCopy(fitness)
+			cut := int(float64(len(sorted)) * trim)
+			return mean(sorted[cut : len(sorted)-cut])
+		}, nil
+	case AggregateCVaR:
+		if alpha <= 0 || alpha > 1 {
+			return nil, fmt.Errorf("cvar alpha must be in (0, 1], got %f", alpha)
+		}
+		return func(fitness []float64) float64 {
+			sorted := sortedCopy(fitness)
+			n := int(math.Ceil(float64(len(sorted)) * alpha))
+			return mean(sorted[:n])
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown aggregate: %s", name)
+	}
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func minimum(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	res := values[0]
+	for _, v := range values[1:] {
+		res = math.Min(res, v)
+	}
+	return res
+}
+
+// median returns the median of sorted values.
+func median(sorted []float64) float64 {
+	n := len(sorted)
+	if n == 0 {
+		return 0
+	}
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+func sortedCopy(values []float64) []float64 {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	return sorted
+}
@@ -0,0 +1,275 @@
+package neapy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/yaricom/goNEAT/v4/experiment"
+	"github.com/yaricom/goNEAT/v4/neat"
+	"github.com/yaricom/goNEAT/v4/neat/genetics"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	checkpointPopulationFile = "population"
+	checkpointStateFile      = "state.yaml"
+	checkpointExperimentFile = "experiment.dat"
+)
+
+// ErrNoCheckpoint is returned when there is no checkpoint to resume from.
+var ErrNoCheckpoint = errors.New("no checkpoint found")
+
+// Checkpoint is the evolution state not covered by the population genomes.
+type Checkpoint struct {
+	// Run is the trial being executed.
+	Run int `yaml:"run"`
+	// Generation is the next generation to evaluate.
+	Generation int `yaml:"generation"`
+	// Seed is the experiment seed, every generation reseeds the random source from it.
+	Seed int64 `yaml:"seed"`
+	// Elapsed is the duration of the trial so far.
+	Elapsed time.Duration `yaml:"elapsed"`
+
+	NextNodeId               int     `yaml:"next_node_id"`
+	NextInnovationNumber     int64   `yaml:"next_innovation_number"`
+	LastSpecies              int     `yaml:"last_species"`
+	WinnerGen                int     `yaml:"winner_gen"`
+	HighestFitness           float64 `yaml:"highest_fitness"`
+	EpochsHighestLastChanged int     `yaml:"epochs_highest_last_changed"`
+
+	Species []SpeciesCheckpoint `yaml:"species"`
+
+	// NoveltyArchive holds the behaviours archived by novelty search.
+	NoveltyArchive [][]float64 `yaml:"novelty_archive,omitempty"`
+	// Champions are the recent generation champions replayed as ghosts, oldest first.
+	Champions []ChampionCheckpoint `yaml:"champions,omitempty"`
+}
+
+// ChampionCheckpoint is a generation champion kept to be replayed as a ghost.
+type ChampionCheckpoint struct {
+	Name    string       `yaml:"name"`
+	Network *NetworkFile `yaml:"network"`
+}
+
+// evaluatorState is implemented by evaluators keeping state across generations, it is saved in checkpoints
+// so a resumed run continues like the uninterrupted one.
+type evaluatorState interface {
+	saveState(cp *Checkpoint)
+	restoreState(cp *Checkpoint)
+}
+
+// SpeciesCheckpoint is the state of a single species, organisms are referenced by genome ID.
+type SpeciesCheckpoint struct {
+	Id                   int     `yaml:"id"`
+	Age                  int     `yaml:"age"`
+	AgeOfLastImprovement int     `yaml:"age_of_last_improvement"`
+	MaxFitnessEver       float64 `yaml:"max_fitness_ever"`
+	ExpectedOffspring    int     `yaml:"expected_offspring"`
+	IsNovel              bool    `yaml:"is_novel"`
+	Organisms            []int   `yaml:"organisms"`
+	Generations          []int   `yaml:"generations"`
+}
+
+// writeCheckpoint saves the population and the experiment progress into its own directory under dir.
+func writeCheckpoint(dir string, cp *Checkpoint, pop *genetics.Population, expt *experiment.Experiment) error {
+	// The population counters can only be read by advancing them. Advancing twice guarantees
+	// the saved values are above anything the population genomes hold, see restore.
+	pop.NextNodeId()
+	cp.NextNodeId = pop.NextNodeId()
+	pop.NextInnovationNumber()
+	cp.NextInnovationNumber = pop.NextInnovationNumber()
+	cp.LastSpecies = pop.LastSpecies
+	cp.WinnerGen = pop.WinnerGen
+	cp.HighestFitness = pop.HighestFitness
+	cp.EpochsHighestLastChanged = pop.EpochsHighestLastChanged
+	cp.Species = make([]SpeciesCheckpoint, 0, len(pop.Species))
+	for _, sp := range pop.Species {
+		spc := SpeciesCheckpoint{
+			Id:                   sp.Id,
+			Age:                  sp.Age,
+			AgeOfLastImprovement: sp.AgeOfLastImprovement,
+			MaxFitnessEver:       sp.MaxFitnessEver,
+			ExpectedOffspring:    sp.ExpectedOffspring,
+			IsNovel:              sp.IsNovel,
+		}
+		for _, org := range sp.Organisms {
+			spc.Organisms = append(spc.Organisms, org.Genotype.Id)
+			spc.Generations = append(spc.Generations, org.Generation)
+		}
+		cp.Species = append(cp.Species, spc)
+	}
+
+	cpDir := filepath.Join(dir, runGenName(cp.Run, cp.Generation))
+	if err := os.MkdirAll(cpDir, os.ModePerm); err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(cpDir, checkpointPopulationFile), pop.Write); err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(cpDir, checkpointExperimentFile), expt.Write); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	// state goes last, a checkpoint without it is incomplete and ignored
+	return os.WriteFile(filepath.Join(cpDir, checkpointStateFile), data, 0o644)
+}
+
+// readCheckpoint loads the latest complete checkpoint under dir, restoring the population and the experiment progress.
+func readCheckpoint(dir string, opts *neat.Options, expt *experiment.Experiment) (*Checkpoint, *genetics.Population, error) {
+	cpDir, err := latestCheckpoint(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(cpDir, checkpointStateFile))
+	if err != nil {
+		return nil, nil, err
+	}
+	cp := &Checkpoint{}
+	if err = yaml.Unmarshal(data, cp); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode checkpoint state: %w", err)
+	}
+
+	popFile, err := os.Open(filepath.Join(cpDir, checkpointPopulationFile))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer popFile.Close()
+	pop, err := genetics.ReadPopulation(popFile, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read checkpoint population: %w", err)
+	}
+	if err = cp.restore(pop); err != nil {
+		return nil, nil, err
+	}
+
+	exptFile, err := os.Open(filepath.Join(cpDir, checkpointExperimentFile))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer exptFile.Close()
+	if err = expt.Read(exptFile); err != nil {
+		return nil, nil, fmt.Errorf("failed to read checkpoint experiment: %w", err)
+	}
+	neat.InfoLog(fmt.Sprintf("Resuming from checkpoint '%s'", cpDir))
+	return cp, pop, nil
+}
+
+// restore brings the freshly read population back to the checkpointed state: species, their members and counters.
+func (cp *Checkpoint) restore(pop *genetics.Population) error {
+	organisms := make(map[int]*genetics.Organism, len(pop.Organisms))
+	for _, org := range pop.Organisms {
+		organisms[org.Genotype.Id] = org
+	}
+	pop.Species = make([]*genetics.Species, 0, len(cp.Species))
+	for _, spc := range cp.Species {
+		sp := genetics.NewSpeciesNovel(spc.Id, spc.IsNovel)
+		sp.Age = spc.Age
+		sp.AgeOfLastImprovement = spc.AgeOfLastImprovement
+		sp.MaxFitnessEver = spc.MaxFitnessEver
+		sp.ExpectedOffspring = spc.ExpectedOffspring
+		for i, id := range spc.Organisms {
+			org, ok := organisms[id]
+			if !ok {
+				return fmt.Errorf("organism %d of species %d is missing in checkpoint population", id, spc.Id)
+			}
+			org.Species = sp
+			org.Generation = spc.Generations[i]
+			sp.Organisms = append(sp.Organisms, org)
+		}
+		pop.Species = append(pop.Species, sp)
+	}
+	pop.LastSpecies = cp.LastSpecies
+	pop.WinnerGen = cp.WinnerGen
+	pop.HighestFitness = cp.HighestFitness
+	pop.EpochsHighestLastChanged = cp.EpochsHighestLastChanged
+
+	// advance counters to the saved values
+	for pop.NextNodeId() < cp.NextNodeId {
+	}
+	for pop.NextInnovationNumber() < cp.NextInnovationNumber {
+	}
+	return nil
+}
+
+// saveState saves the novelty archive.
+func (s *scorer) saveState(cp *Checkpoint) {
+	if s.novelty == nil {
+		return
+	}
+	cp.NoveltyArchive = make([][]float64, len(s.novelty.archive))
+	for i, b := range s.novelty.archive {
+		cp.NoveltyArchive[i] = b
+	}
+}
+
+// restoreState restores the novelty archive, it is dropped if the run does not use novelty search anymore.
+func (s *scorer) restoreState(cp *Checkpoint) {
+	if s.novelty == nil {
+		return
+	}
+	s.novelty.archive = make([]behaviour, len(cp.NoveltyArchive))
+	for i, b := range cp.NoveltyArchive {
+		s.novelty.archive[i] = b
+	}
+}
+
+// saveState saves the novelty archive and the champions replayed as ghosts.
+func (e *flappyEvaluator) saveState(cp *Checkpoint) {
+	e.scorer.saveState(cp)
+	cp.Champions = make([]ChampionCheckpoint, len(e.champions))
+	for i, champion := range e.champions {
+		cp.Champions[i] = ChampionCheckpoint{Name: champion.name, Network: champion.nf}
+	}
+}
+
+// restoreState restores the novelty archive and the champions, as many of the latest ones as ghosts are replayed.
+func (e *flappyEvaluator) restoreState(cp *Checkpoint) {
+	e.scorer.restoreState(cp)
+	champions := cp.Champions[max(len(cp.Champions)-e.cfg.Ghosts, 0):]
+	e.champions = make([]ghostChampion, len(champions))
+	for i, champion := range champions {
+		e.champions[i] = ghostChampion{name: champion.Name, nf: champion.Network}
+	}
+}
+
+func (e *parallelEvaluator) saveState(cp *Checkpoint)    { e.scorer.saveState(cp) }
+func (e *parallelEvaluator) restoreState(cp *Checkpoint) { e.scorer.restoreState(cp) }
+
+// runGenName names files of the generation in the run.
+func runGenName(run, generation int) string {
+	return fmt.Sprintf("run%02d_gen%05d", run, generation)
+}
+
+// latestCheckpoint returns the directory of the most recent complete checkpoint under dir.
+func latestCheckpoint(dir string) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "run*_gen*", checkpointStateFile))
+	if err != nil {
+		return "", err
+	}
+	if len(matches) == 0 {
+		return "", ErrNoCheckpoint
+	}
+	// names are zero padded, lexical order is chronological
+	sort.Strings(matches)
+	return filepath.Dir(matches[len(matches)-1]), nil
+}
+
+func writeFile(path string, write func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = write(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
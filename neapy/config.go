@@ -0,0 +1,122 @@
+package neapy
+
+import (
+	"fmt"
+	"gographics/game"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds the flappy evaluator settings.
+type Config struct {
+	// Episodes is the number of seeded courses every organism is evaluated on.
+	Episodes int `yaml:"episodes"`
+	// Seed is the seed of the first course, the following courses use the next seeds.
+	// 0 draws new course seeds every generation.
+	Seed int64 `yaml:"seed"`
+	// Sensors is the sensor manifest: the names of the network inputs in the order of the genome input nodes.
+	Sensors []string `yaml:"sensors"`
+	// Fitness is the name of the fitness function scoring a single course, see FitnessNames.
+	Fitness string `yaml:"fitness"`
+	// FitnessParams are the parameters of the fitness function, missing ones keep their defaults.
+	FitnessParams FitnessParams `yaml:"fitness_params"`
+	// SolvePipes is the number of pipes an organism has to pass on every course to solve the experiment.
+	SolvePipes int `yaml:"solve_pipes"`
+	// Search is what organisms are selected for: fitness, novelty or pareto.
+	Search string `yaml:"search"`
+	// Novelty holds the novelty search settings.
+	Novelty NoveltyConfig `yaml:"novelty"`
+	// Pareto holds the multi-objective search settings.
+	Pareto ParetoConfig `yaml:"pareto"`
+	// Aggregate is the statistic combining fitness over courses: mean, min, trimmed_mean or cvar.
+	Aggregate string `yaml:"aggregate"`
+	// Trim is the share of the best and of the worst courses dropped by trimmed_mean.
+	Trim float64 `yaml:"trim"`
+	// CVaRAlpha is the share of the worst courses averaged by cvar.
+	CVaRAlpha float64 `yaml:"cvar_alpha"`
+
+	// Ghosts is the number of recent generation champions replayed as ghosts in the game window, 0 shows none.
+	Ghosts int `yaml:"ghosts"`
+
+	// Parallel evaluates organisms on headless games across CPU cores instead of the game window.
+	Parallel bool `yaml:"parallel"`
+	// Workers is the number of parallel workers, 0 uses all CPU cores.
+	Workers int `yaml:"workers"`
+
+	// OutDir is where the evaluator writes its reports, like Pareto fronts. Set by the command line, nothing is written if empty.
+	OutDir string `yaml:"-"`
+}
+
+// DefaultConfig evaluates every organism on a single random course.
+func DefaultConfig() *Config {
+	return &Config{
+		Episodes:   1,
+		Sensors:    DefaultSensors,
+		Fitness:    FitnessSurvivalSquared,
+		SolvePipes: 50,
+		Search:     SearchFitness,
+		Novelty: NoveltyConfig{
+			K:           15,
+			ArchiveSize: 500,
+			ArchiveAdd:  5,
+			Pipes:       5,
+		},
+		Pareto: ParetoConfig{
+			Preference: map[string]float64{ObjectiveSurvival: 1},
+		},
+		Aggregate: AggregateMean,
+		Trim:      0.1,
+		CVaRAlpha: 0.25,
+	}
+}
+
+// ReadConfigFromFile reads the config, settings missing in the file keep their defaults.
+func ReadConfigFromFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := DefaultConfig()
+	if err = yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode config: %w", err)
+	}
+	return cfg, cfg.Validate()
+}
+
+// WriteFile writes the config, with the defaults resolved, to path.
+func (c *Config) WriteFile(path string) error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Validate checks the settings are consistent.
+func (c *Config) Validate() error {
+	if c.Episodes < 1 {
+		return fmt.Errorf("episodes must be positive, got %d", c.Episodes)
+	}
+	if _, err := NewSensors(c.Sensors); err != nil {
+		return err
+	}
+	if c.SolvePipes < 1 {
+		return fmt.Errorf("solve_pipes must be positive, got %d", c.SolvePipes)
+	}
+	if c.Ghosts < 0 {
+		return fmt.Errorf("ghosts must not be negative, got %d", c.Ghosts)
+	}
+	_, err := newScorer(c)
+	return err
+}
+
+// MaxFitness returns the course fitness of an organism solving it in a window of the given width:
+// passing the pipes without a jump, right through the gap centres.
+func (c *Config) MaxFitness(windowW int) (float64, error) {
+	fitness, err := NewFitness(c.Fitness, c.FitnessParams)
+	if err != nil {
+		return 0, err
+	}
+	return fitness(episodeRecord{steps: game.PipeSteps(windowW, c.SolvePipes), pipes: c.SolvePipes}), nil
+}
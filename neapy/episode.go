@@ -0,0 +1,189 @@
+package neapy
+
+import (
+	"fmt"
+	"gographics/game"
+	"math"
+	"path/filepath"
+
+	"github.com/yaricom/goNEAT/v4/experiment"
+	"github.com/yaricom/goNEAT/v4/neat"
+	"github.com/yaricom/goNEAT/v4/neat/genetics"
+)
+
+// episodeRecord is what an organism achieved on a single course. It comes from the game record,
+// which stops counting when the gopher dies, so the fitness earned before death is kept.
+type episodeRecord struct {
+	steps       int
+	pipes       int
+	jumps       int
+	gapDistance float64
+	passOffsets []float64
+	lastY       float64
+}
+
+func newEpisodeRecord(rec game.Record) episodeRecord {
+	return episodeRecord{
+		steps:       rec.Steps,
+		pipes:       rec.Pipes,
+		jumps:       rec.Jumps,
+		gapDistance: rec.GapDistance,
+		passOffsets: rec.PassOffsets,
+		lastY:       rec.LastY,
+	}
+}
+
+// scorer turns episode records into organism fitness.
+type scorer struct {
+	fitness   FitnessFunc
+	aggregate Aggregate
+	// solvePipes is the number of pipes to pass on every course to solve the experiment.
+	solvePipes int
+	// novelty ranks organisms instead of the fitness in novelty search, nil otherwise.
+	novelty *noveltySearch
+	// pareto ranks organisms instead of the fitness in multi-objective search, nil otherwise.
+	pareto *paretoSearch
+	// stats are the statistics of the last scored generation.
+	stats GenerationStats
+}
+
+func newScorer(cfg *Config) (*scorer, error) {
+	fitness, err := NewFitness(cfg.Fitness, cfg.FitnessParams)
+	if err != nil {
+		return nil, err
+	}
+	aggregate, err := NewAggregate(cfg.Aggregate, cfg.Trim, cfg.CVaRAlpha)
+	if err != nil {
+		return nil, err
+	}
+	s := &scorer{fitness: fitness, aggregate: aggregate, solvePipes: cfg.SolvePipes}
+	switch cfg.Search {
+	case SearchFitness:
+	case SearchNovelty:
+		if err = cfg.Novelty.validate(); err != nil {
+			return nil, err
+		}
+		s.novelty = &noveltySearch{cfg: cfg.Novelty}
+	case SearchPareto:
+		if err = cfg.Pareto.validate(); err != nil {
+			return nil, err
+		}
+		s.pareto = &paretoSearch{cfg: cfg.Pareto}
+		if cfg.OutDir != "" {
+			s.pareto.dir = filepath.Join(cfg.OutDir, "pareto")
+		}
+	default:
+		return nil, fmt.Errorf("unknown search '%s', expected %s, %s or %s",
+			cfg.Search, SearchFitness, SearchNovelty, SearchPareto)
+	}
+	return s, nil
+}
+
+// solved reports whether the organism passed enough pipes to win the course.
+func (s *scorer) solved(rec episodeRecord) bool {
+	return rec.pipes >= s.solvePipes
+}
+
+// EpisodeStats is the outcome of the population on a single course.
+type EpisodeStats struct {
+	Seed        int64
+	BestFitness float64
+	MeanFitness float64
+	BestPipes   int
+	MeanPipes   float64
+}
+
+// scoreGeneration sets organisms fitness aggregated over the courses, logs per course statistics
+// and marks the first organism which solved every course as the winner.
+// records holds the episodes of every organism in the order of seeds.
+func scoreGeneration(pop *genetics.Population, seeds []int64, records [][]episodeRecord, s *scorer,
+	epoch *experiment.Generation, options *neat.Options) {
+	for i, agent := range pop.Organisms {
+		fitness := make([]float64, len(seeds))
+		for j, rec := range records[i] {
+			fitness[j] = s.fitness(rec)
+		}
+		agent.Fitness = s.aggregate(fitness)
+		agent.Data = &genetics.OrganismData{Value: agent.Fitness}
+		agent.IsWinner = false
+	}
+	s.stats = newGenerationStats(pop, records)
+	if s.novelty != nil {
+		s.novelty.score(pop, records)
+	}
+	if s.pareto != nil {
+		s.pareto.score(pop, records, s.aggregate, epoch)
+	}
+
+	for j, seed := range seeds {
+		stats := EpisodeStats{Seed: seed, BestFitness: math.Inf(-1)}
+		episode := make([]float64, len(records))
+		pipes := make([]float64, len(records))
+		for i := range records {
+			rec := records[i][j]
+			episode[i] = s.fitness(rec)
+			pipes[i] = float64(rec.pipes)
+			stats.BestFitness = math.Max(stats.BestFitness, episode[i])
+			stats.BestPipes = max(stats.BestPipes, rec.pipes)
+		}
+		stats.MeanFitness = mean(episode)
+		stats.MeanPipes = mean(pipes)
+		s.stats.Episodes = append(s.stats.Episodes, stats)
+		logEpisode(stats)
+	}
+
+	for i, agent := range pop.Organisms {
+		solved := true
+		for _, rec := range records[i] {
+			solved = solved && s.solved(rec)
+		}
+		if !solved {
+			continue
+		}
+		agent.IsWinner = true
+		epoch.Solved = true
+		epoch.WinnerNodes = len(agent.Genotype.Nodes)
+		epoch.WinnerGenes = agent.Genotype.Extrons()
+		epoch.WinnerEvals = options.PopSize*epoch.Id + agent.Genotype.Id
+		epoch.Champion = agent
+		return
+	}
+}
+
+// fillStatistics fills the generation statistics. In novelty search the champion is the organism
+// with the best objective fitness rather than the most novel one, in multi-objective search
+// it is the preferred organism of the first front.
+func (s *scorer) fillStatistics(pop *genetics.Population, epoch *experiment.Generation) {
+	epoch.FillPopulationStatistics(pop)
+	if epoch.Solved {
+		return
+	}
+	if s.pareto != nil && s.pareto.champion != nil {
+		epoch.Champion = s.pareto.champion
+		return
+	}
+	if s.novelty == nil {
+		return
+	}
+	for _, agent := range pop.Organisms {
+		if epoch.Champion == nil || objectiveFitness(agent) > objectiveFitness(epoch.Champion) {
+			epoch.Champion = agent
+		}
+	}
+}
+
+// objectiveFitness returns the fitness aggregated over the courses, which is what the organism
+// achieved whatever it was selected for.
+func objectiveFitness(org *genetics.Organism) float64 {
+	if org.Data != nil {
+		if fitness, ok := org.Data.Value.(float64); ok {
+			return fitness
+		}
+	}
+	return org.Fitness
+}
+
+func logEpisode(stats EpisodeStats) {
+	neat.InfoLog(fmt.Sprintf("Course seed %d: best fitness %.2f, mean fitness %.2f, best pipes %d, mean pipes %.2f",
+		stats.Seed, stats.BestFitness, stats.MeanFitness, stats.BestPipes, stats.MeanPipes))
+}
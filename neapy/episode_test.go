@@ -0,0 +1,81 @@
+package neapy
+
+import (
+	"gographics/game"
+	"testing"
+
+	"github.com/yaricom/goNEAT/v4/experiment"
+	"github.com/yaricom/goNEAT/v4/neat"
+	"github.com/yaricom/goNEAT/v4/neat/genetics"
+)
+
+func TestNewEpisodeRecord(t *testing.T) {
+	rec := game.Record{ID: 3, Steps: 250, Pipes: 2, Jumps: 17, GapDistance: 0.25,
+		PassOffsets: []float64{0.1, -0.05}, LastY: 0.8, Alive: false}
+	got := newEpisodeRecord(rec)
+	if got.steps != 250 || got.pipes != 2 || got.jumps != 17 || got.gapDistance != 0.25 || got.lastY != 0.8 {
+		t.Errorf("record of a dead gopher is not kept: %+v", got)
+	}
+	if len(got.passOffsets) != 2 || got.passOffsets[1] != -0.05 {
+		t.Errorf("pass offsets are not kept: %v", got.passOffsets)
+	}
+}
+
+// TestScoreGenerationRanking checks organisms dying later or after more pipes are always ranked higher,
+// the fitness earned before death is not lost.
+func TestScoreGenerationRanking(t *testing.T) {
+	// gophers in ascending order of what they achieved on every course
+	deaths := [][]game.Record{
+		{{Steps: 30}, {Steps: 25}},
+		{{Steps: 90}, {Steps: 80}},
+		{{Steps: 200, Pipes: 1}, {Steps: 210, Pipes: 1}},
+		{{Steps: 320, Pipes: 2}, {Steps: 300, Pipes: 2}},
+		{{Steps: 800, Pipes: 6}, {Steps: 700, Pipes: 5}},
+	}
+	tests := []struct {
+		fitness   string
+		aggregate string
+	}{
+		{FitnessSurvivalSquared, AggregateMean},
+		{FitnessPipesPassed, AggregateMean},
+		{FitnessEnergyPenalized, AggregateMean},
+		{FitnessSurvivalSquared, AggregateMin},
+		{FitnessPipesPassed, AggregateTrimmedMean},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fitness+"/"+tt.aggregate, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Fitness = tt.fitness
+			cfg.Aggregate = tt.aggregate
+			cfg.SolvePipes = 50
+			s, err := newScorer(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pop := &genetics.Population{}
+			records := make([][]episodeRecord, len(deaths))
+			// shuffled, so the ranking does not come from the population order
+			order := []int{3, 0, 4, 2, 1}
+			for i, d := range order {
+				pop.Organisms = append(pop.Organisms, &genetics.Organism{})
+				for _, rec := range deaths[d] {
+					records[i] = append(records[i], newEpisodeRecord(rec))
+				}
+			}
+			scoreGeneration(pop, []int64{1, 2}, records, s, &experiment.Generation{}, &neat.Options{})
+
+			fitness := make([]float64, len(deaths))
+			for i, d := range order {
+				fitness[d] = pop.Organisms[i].Fitness
+			}
+			for d := 1; d < len(fitness); d++ {
+				if fitness[d] <= fitness[d-1] {
+					t.Errorf("gopher %d ranked %f, not above gopher %d ranked %f", d, fitness[d], d-1, fitness[d-1])
+				}
+			}
+			if s.stats.BestPipes != 6 {
+				t.Errorf("best pipes is %d, expected 6", s.stats.BestPipes)
+			}
+		})
+	}
+}
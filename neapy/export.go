@@ -0,0 +1,253 @@
+package neapy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/yaricom/goNEAT/v4/experiment"
+	"github.com/yaricom/goNEAT/v4/neat"
+	"github.com/yaricom/goNEAT/v4/neat/genetics"
+	"github.com/yaricom/goNEAT/v4/neat/math"
+	"github.com/yaricom/goNEAT/v4/neat/network"
+)
+
+const (
+	championGenomeFile  = "champion.yaml"
+	championNetworkFile = "champion.json"
+)
+
+// NetworkFile is the compact JSON form of a phenotype: only what is needed to activate it.
+type NetworkFile struct {
+	Id      int     `json:"id"`
+	Fitness float64 `json:"fitness"`
+	// Sensors is the sensor manifest the network was trained with.
+	Sensors []string      `json:"sensors,omitempty"`
+	Nodes   []NetworkNode `json:"nodes"`
+	Links   []NetworkLink `json:"links"`
+}
+
+type NetworkNode struct {
+	Id         int    `json:"id"`
+	Type       string `json:"type"`
+	Activation string `json:"activation"`
+}
+
+type NetworkLink struct {
+	From      int     `json:"from"`
+	To        int     `json:"to"`
+	Weight    float64 `json:"weight"`
+	Recurrent bool    `json:"recurrent,omitempty"`
+}
+
+// NewNetworkFile describes the phenotype of the organism.
+func NewNetworkFile(org *genetics.Organism) (*NetworkFile, error) {
+	nf := &NetworkFile{
+		Id:      org.Genotype.Id,
+		Fitness: objectiveFitness(org),
+	}
+	for _, n := range org.Genotype.Nodes {
+		activation, err := math.NodeActivators.ActivationNameFromType(n.ActivationType)
+		if err != nil {
+			return nil, err
+		}
+		nf.Nodes = append(nf.Nodes, NetworkNode{
+			Id:         n.Id,
+			Type:       network.NeuronTypeName(n.NeuronType),
+			Activation: activation,
+		})
+	}
+	for _, gene := range org.Genotype.Genes {
+		if !gene.IsEnabled {
+			continue
+		}
+		nf.Links = append(nf.Links, NetworkLink{
+			From:      gene.Link.InNode.Id,
+			To:        gene.Link.OutNode.Id,
+			Weight:    gene.Link.ConnectionWeight,
+			Recurrent: gene.Link.IsRecurrent,
+		})
+	}
+	return nf, nil
+}
+
+// Network builds the phenotype described by the file.
+func (nf *NetworkFile) Network() (*network.Network, error) {
+	var inList, outList, allList []*network.NNode
+	nodes := make(map[int]*network.NNode, len(nf.Nodes))
+	for _, n := range nf.Nodes {
+		neuronType, err := network.NeuronTypeByName(n.Type)
+		if err != nil {
+			return nil, err
+		}
+		activation, err := math.NodeActivators.ActivationTypeFromName(n.Activation)
+		if err != nil {
+			return nil, err
+		}
+		node := network.NewNNode(n.Id, neuronType)
+		node.ActivationType = activation
+		switch neuronType {
+		case network.InputNeuron, network.BiasNeuron:
+			inList = append(inList, node)
+		case network.OutputNeuron:
+			outList = append(outList, node)
+		}
+		allList = append(allList, node)
+		nodes[n.Id] = node
+	}
+	if len(outList) == 0 {
+		return nil, fmt.Errorf("network %d has no outputs", nf.Id)
+	}
+	for _, l := range nf.Links {
+		in, out := nodes[l.From], nodes[l.To]
+		if in == nil || out == nil {
+			return nil, fmt.Errorf("link %d -> %d refers to a missing node", l.From, l.To)
+		}
+		link := network.NewLink(l.Weight, in, out, l.Recurrent)
+		out.Incoming = append(out.Incoming, link)
+		in.Outgoing = append(in.Outgoing, link)
+	}
+	return network.NewNetwork(inList, outList, allList, nf.Id), nil
+}
+
+// ReadNetworkFile reads the NetworkFile JSON.
+func ReadNetworkFile(path string) (*NetworkFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	nf := &NetworkFile{}
+	if err = json.Unmarshal(data, nf); err != nil {
+		return nil, fmt.Errorf("failed to decode network file: %w", err)
+	}
+	return nf, nil
+}
+
+// LoadNetwork builds a phenotype from file. JSON files are read as NetworkFile, anything else as a goNEAT genome.
+func LoadNetwork(path string) (*network.Network, error) {
+	if filepath.Ext(path) == ".json" {
+		nf, err := ReadNetworkFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return nf.Network()
+	}
+	reader, err := genetics.NewGenomeReaderFromFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open genome file: %w", err)
+	}
+	genome, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read genome: %w", err)
+	}
+	return genome.Genesis(genome.Id)
+}
+
+// LoadController builds a network controller from file. A NetworkFile brings its own sensor manifest,
+// genomes are fed by the given one. The network is checked against the manifest.
+func LoadController(path string, sensors *Sensors) (*NetworkController, error) {
+	var (
+		net *network.Network
+		err error
+	)
+	if filepath.Ext(path) == ".json" {
+		// the file is decoded once for both the network and its manifest
+		var nf *NetworkFile
+		if nf, err = ReadNetworkFile(path); err != nil {
+			return nil, err
+		}
+		if len(nf.Sensors) > 0 {
+			if sensors, err = NewSensors(nf.Sensors); err != nil {
+				return nil, err
+			}
+		}
+		net, err = nf.Network()
+	} else {
+		net, err = LoadNetwork(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = sensors.CheckNetwork(net); err != nil {
+		return nil, err
+	}
+	return NewNetworkController(net, sensors), nil
+}
+
+// SaveGenome writes the genome in goNEAT YAML encoding.
+func SaveGenome(path string, genome *genetics.Genome) error {
+	return writeFile(path, func(w io.Writer) error {
+		gw, err := genetics.NewGenomeWriter(w, genetics.YAMLGenomeEncoding)
+		if err != nil {
+			return err
+		}
+		return gw.WriteGenome(genome)
+	})
+}
+
+// SaveNetwork writes the organism phenotype as NetworkFile JSON along with its sensor manifest.
+func SaveNetwork(path string, org *genetics.Organism, sensors *Sensors) error {
+	nf, err := NewNetworkFile(org)
+	if err != nil {
+		return err
+	}
+	nf.Sensors = sensors.Names()
+	data, err := json.Marshal(nf)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// ChampionSaver is a trial observer saving the champion of every generation and the best champion overall.
+type ChampionSaver struct {
+	dir     string
+	sensors *Sensors
+	// best is the fitness of the saved champion, valid if there is one.
+	best  float64
+	saved bool
+}
+
+// NewChampionSaver creates a saver writing into dir the champions trained with the sensor manifest.
+// When the run is resumed, the champion already saved there, if any, has to be beaten to be replaced.
+// A new run replaces it with its first champion, its fitness may be on another scale.
+func NewChampionSaver(dir string, sensors *Sensors, resume bool) (*ChampionSaver, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "generations"), os.ModePerm); err != nil {
+		return nil, err
+	}
+	s := &ChampionSaver{dir: dir, sensors: sensors}
+	if !resume {
+		return s, nil
+	}
+	if nf, err := ReadNetworkFile(filepath.Join(dir, championNetworkFile)); err == nil {
+		s.best, s.saved = nf.Fitness, true
+	}
+	return s, nil
+}
+
+func (s *ChampionSaver) TrialRunStarted(*experiment.Trial) {}
+
+func (s *ChampionSaver) TrialRunFinished(*experiment.Trial) {}
+
+func (s *ChampionSaver) EpochEvaluated(trial *experiment.Trial, epoch *experiment.Generation) {
+	champion := epoch.Champion
+	if champion == nil {
+		return
+	}
+	genPath := filepath.Join(s.dir, "generations", runGenName(trial.Id, epoch.Id)+".yaml")
+	if err := SaveGenome(genPath, champion.Genotype); err != nil {
+		neat.ErrorLog(fmt.Sprintf("Failed to save generation champion: %s", err))
+	}
+	if s.saved && objectiveFitness(champion) <= s.best {
+		return
+	}
+	s.best, s.saved = objectiveFitness(champion), true
+	if err := SaveGenome(filepath.Join(s.dir, championGenomeFile), champion.Genotype); err != nil {
+		neat.ErrorLog(fmt.Sprintf("Failed to save champion genome: %s", err))
+	}
+	if err := SaveNetwork(filepath.Join(s.dir, championNetworkFile), champion, s.sensors); err != nil {
+		neat.ErrorLog(fmt.Sprintf("Failed to save champion network: %s", err))
+	}
+}
@@ -0,0 +1,109 @@
+package neapy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// The fitness functions known to the registry.
+const (
+	FitnessSurvivalSquared = "survival_squared"
+	FitnessPipesPassed     = "pipes_passed"
+	FitnessGapDistance     = "gap_distance"
+	FitnessEnergyPenalized = "energy_penalized"
+)
+
+// FitnessFunc scores what an organism achieved on a single course.
+type FitnessFunc func(rec episodeRecord) float64
+
+// FitnessParams are the named parameters of a fitness function.
+type FitnessParams map[string]float64
+
+// get returns the parameter or its default when missing.
+func (p FitnessParams) get(name string, def float64) float64 {
+	if v, ok := p[name]; ok {
+		return v
+	}
+	return def
+}
+
+type fitnessFactory struct {
+	// params are the accepted parameters with their defaults.
+	params FitnessParams
+	build  func(p FitnessParams) FitnessFunc
+}
+
+var fitnessRegistry = map[string]fitnessFactory{
+	// survival time squared with a bonus for every passed pipe
+	FitnessSurvivalSquared: {
+		params: FitnessParams{"time_scale": 0.1, "pipe_bonus": 100},
+		build: func(p FitnessParams) FitnessFunc {
+			scale, bonus := p["time_scale"], p["pipe_bonus"]
+			return func(rec episodeRecord) float64 {
+				timeAlive := float64(rec.steps) * scale
+				return timeAlive*timeAlive + bonus*float64(rec.pipes)
+			}
+		},
+	},
+	// passed pipes, survival time only breaks ties
+	FitnessPipesPassed: {
+		params: FitnessParams{"step_weight": 0.001},
+		build: func(p FitnessParams) FitnessFunc {
+			stepWeight := p["step_weight"]
+			return func(rec episodeRecord) float64 {
+				return float64(rec.pipes) + stepWeight*float64(rec.steps)
+			}
+		},
+	},
+	// passed pipes shaped by how close to the centre of the next gap the gopher ended up
+	FitnessGapDistance: {
+		params: FitnessParams{"pipe_reward": 1, "gap_weight": 1},
+		build: func(p FitnessParams) FitnessFunc {
+			reward, gapWeight := p["pipe_reward"], p["gap_weight"]
+			return func(rec episodeRecord) float64 {
+				return reward*float64(rec.pipes) + gapWeight*(1-rec.gapDistance)
+			}
+		},
+	},
+	// survival time with a cost for every jump, the result is never negative
+	FitnessEnergyPenalized: {
+		params: FitnessParams{"step_reward": 1, "pipe_bonus": 100, "jump_cost": 2},
+		build: func(p FitnessParams) FitnessFunc {
+			stepReward, bonus, jumpCost := p["step_reward"], p["pipe_bonus"], p["jump_cost"]
+			return func(rec episodeRecord) float64 {
+				f := stepReward*float64(rec.steps) + bonus*float64(rec.pipes) - jumpCost*float64(rec.jumps)
+				return max(f, 0)
+			}
+		},
+	},
+}
+
+// FitnessNames returns the names of the registered fitness functions.
+func FitnessNames() []string {
+	names := make([]string, 0, len(fitnessRegistry))
+	for name := range fitnessRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewFitness builds the named fitness function, parameters missing in params keep their defaults.
+func NewFitness(name string, params FitnessParams) (FitnessFunc, error) {
+	factory, ok := fitnessRegistry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown fitness function '%s', expected one of: %s",
+			name, strings.Join(FitnessNames(), ", "))
+	}
+	resolved := make(FitnessParams, len(factory.params))
+	for k, def := range factory.params {
+		resolved[k] = params.get(k, def)
+	}
+	for k := range params {
+		if _, ok = factory.params[k]; !ok {
+			return nil, fmt.Errorf("unknown parameter '%s' of fitness function '%s'", k, name)
+		}
+	}
+	return factory.build(resolved), nil
+}
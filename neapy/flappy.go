@@ -4,99 +4,205 @@ import (
 	"context"
 	"fmt"
 	"gographics/game"
+	"math"
+	"math/rand"
+	"sync"
 
 	"github.com/yaricom/goNEAT/v4/experiment"
 	"github.com/yaricom/goNEAT/v4/neat"
 	"github.com/yaricom/goNEAT/v4/neat/genetics"
 )
 
-const fitnessThreshold = 250000.0
-
 type flappyEvaluator struct {
-	gm *game.Game
+	gm      *game.Game
+	cfg     *Config
+	scorer  *scorer
+	sensors *Sensors
+
+	// champions are the recent generation champions replayed as ghosts, oldest first.
+	champions []ghostChampion
+
+	// hud is the training progress shown in the game window.
+	muHUD sync.Mutex
+	hud   game.HUDStats
+}
+
+// hudHistory is the number of recent generations in the HUD sparkline.
+const hudHistory = 50
+
+func NewFlappyEvaluator(game *game.Game, cfg *Config) (experiment.GenerationEvaluator, error) {
+	scorer, err := newScorer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	sensors, err := NewSensors(cfg.Sensors)
+	if err != nil {
+		return nil, err
+	}
+	e := &flappyEvaluator{gm: game, cfg: cfg, scorer: scorer, sensors: sensors}
+	game.SetStatsProvider(e)
+	return e, nil
+}
+
+// HUDStats returns the training progress for the game HUD.
+func (e *flappyEvaluator) HUDStats() game.HUDStats {
+	e.muHUD.Lock()
+	defer e.muHUD.Unlock()
+	stats := e.hud
+	stats.RecentBest = append([]float64(nil), e.hud.RecentBest...)
+	return stats
+}
+
+// updateHUD shows the generation about to be played, and once it is scored, its results.
+func (e *flappyEvaluator) updateHUD(pop *genetics.Population, epoch *experiment.Generation, scored bool) {
+	e.muHUD.Lock()
+	defer e.muHUD.Unlock()
+	if !scored {
+		if epoch.Id < e.hud.Generation {
+			// generations are numbered anew, a new trial run started
+			e.hud = game.HUDStats{}
+		}
+		e.hud.Generation = epoch.Id
+		e.hud.Species = len(pop.Species)
+		return
+	}
+	stats := e.scorer.stats
+	e.hud.BestFitness = max(e.hud.BestFitness, stats.BestFitness)
+	e.hud.RecentBest = append(e.hud.RecentBest, float64(stats.BestPipes))
+	if len(e.hud.RecentBest) > hudHistory {
+		e.hud.RecentBest = e.hud.RecentBest[len(e.hud.RecentBest)-hudHistory:]
+	}
 }
 
-func NewFlappyEvaluator(game *game.Game) experiment.GenerationEvaluator {
-	return &flappyEvaluator{gm: game}
+// GenerationStats returns the statistics of the last evaluated generation.
+func (e *flappyEvaluator) GenerationStats() GenerationStats {
+	return e.scorer.stats
 }
 
 // GenerationEvaluate This method evaluates one epoch for given population and prints results into output directory if any.
+// The whole population plays the configured number of seeded courses together in the game window,
+// the fitness of every organism is the aggregate over them.
 func (e *flappyEvaluator) GenerationEvaluate(ctx context.Context, pop *genetics.Population, epoch *experiment.Generation) error {
-	defer epoch.FillPopulationStatistics(pop)
-	e.gm.Restart(len(pop.Organisms))
+	defer e.scorer.fillStatistics(pop, epoch)
 	options, ok := neat.FromContext(ctx)
 	if !ok {
 		return neat.ErrNEATOptionsNotFound
 	}
-	// while game is not over
-	timeAlive := 0.0
-	lastState := -1
-	for {
-		state, err := e.gm.NextState()
+
+	controllers := make([]*NetworkController, len(pop.Organisms))
+	for i, agent := range pop.Organisms {
+		pheno, err := agent.Phenotype()
 		if err != nil {
-			break
+			return err
 		}
-		if state.ID == lastState {
-			fmt.Println(state.ID)
-			continue
+		if err = e.sensors.CheckNetwork(pheno); err != nil {
+			return err
 		}
-		lastState = state.ID
-		timeAlive += 0.1
-		actions := make(map[int]bool, len(pop.Organisms))
-		for i, agent := range pop.Organisms {
-			gState, ok := state.GophersState[i]
-			if !ok {
-				// skip losers
-				agent.Fitness = -10
-				continue
-			}
-			pheno, err := agent.Phenotype()
-			if err != nil {
-				return err
-			}
-			depth, err := pheno.MaxActivationDepth()
-			if err != nil {
-				return err
-			}
-			// feed forward
-			out := 0.0
-			x := []float64{gState.PosYpercent, gState.SpeedY, state.PipeBotY, state.PipeTopY}
-			if err := pheno.LoadSensors(x); err != nil {
-				return err
-			}
-			if _, err := pheno.ForwardSteps(depth); err != nil {
-				return err
-			}
-			out = pheno.ReadOutputs()[0]
-			if _, err := pheno.Flush(); err != nil {
+		controllers[i] = NewNetworkController(pheno, e.sensors)
+		e.gm.SetController(i, controllers[i])
+	}
+	e.gm.SetGopherInfo(gopherInfo(pop))
+	e.updateHUD(pop, epoch, false)
+	e.gm.SetPipeLimit(e.cfg.SolvePipes)
+
+	seeds := courseSeeds(e.cfg)
+	e.gm.SetGhosts(e.recordGhosts(seeds))
+	records := make([][]episodeRecord, len(pop.Organisms))
+	for _, seed := range seeds {
+		e.gm.SetSeed(seed)
+		e.gm.Restart(len(pop.Organisms))
+		select {
+		case <-e.gm.Done():
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		for _, c := range controllers {
+			if err := c.Err(); err != nil {
 				return err
 			}
-			// perform actual action in game to progress through states
-			if out > 0.5 {
-				actions[i] = true
-			}
-			// calc fitness
-			agent.Fitness = float64(timeAlive * timeAlive)
-			// are we there yet?
-			if agent.Fitness > fitnessThreshold {
-				epoch.Solved = true
-				agent.IsWinner = true
-				epoch.Solved = true
-				epoch.WinnerNodes = len(agent.Genotype.Nodes)
-				epoch.WinnerGenes = agent.Genotype.Extrons()
-				epoch.WinnerEvals = options.PopSize*epoch.Id + agent.Genotype.Id
-				epoch.Champion = agent
-				neat.InfoLog(fmt.Sprintf(">>>> Output activations: %e\n", out))
-				return nil
-			} else {
-				agent.IsWinner = false
-			}
 		}
-		err = e.gm.SyncInput(actions)
-		if err != nil {
-			break
-			// return fmt.Errorf("sync input send failed: %w", err)
+		episode := e.gm.Records()
+		for i := range pop.Organisms {
+			records[i] = append(records[i], newEpisodeRecord(episode[i]))
 		}
 	}
+	scoreGeneration(pop, seeds, records, e.scorer, epoch, options)
+	e.updateHUD(pop, epoch, true)
+	e.keepChampion(pop, epoch)
 	return nil
 }
+
+// courseSeeds returns the seeds of the courses to evaluate this generation.
+func courseSeeds(cfg *Config) []int64 {
+	seeds := make([]int64, cfg.Episodes)
+	for i := range seeds {
+		if cfg.Seed != 0 {
+			seeds[i] = cfg.Seed + int64(i)
+		} else {
+			// 0 is reserved for unseeded courses
+			seeds[i] = rand.Int63n(math.MaxInt64-1) + 1
+		}
+	}
+	return seeds
+}
+
+// gopherInfo groups the gophers by the species of their organisms and labels them with the genome ids.
+func gopherInfo(pop *genetics.Population) map[int]game.GopherInfo {
+	infos := make(map[int]game.GopherInfo, len(pop.Organisms))
+	for i, agent := range pop.Organisms {
+		info := game.GopherInfo{Label: fmt.Sprintf("#%d", agent.Genotype.Id)}
+		if agent.Species != nil {
+			info.Group = agent.Species.Id
+		}
+		infos[i] = info
+	}
+	return infos
+}
+
+// ghostChampion is a generation champion to replay.
+type ghostChampion struct {
+	name string
+	nf   *NetworkFile
+}
+
+// keepChampion keeps the organism with the best objective fitness to replay it as a ghost in the next generations.
+func (e *flappyEvaluator) keepChampion(pop *genetics.Population, epoch *experiment.Generation) {
+	if e.cfg.Ghosts == 0 || len(pop.Organisms) == 0 {
+		return
+	}
+	best := pop.Organisms[0]
+	for _, agent := range pop.Organisms[1:] {
+		if objectiveFitness(agent) > objectiveFitness(best) {
+			best = agent
+		}
+	}
+	// the network is built anew from the file, so the organism phenotype is left alone
+	nf, err := NewNetworkFile(best)
+	if err != nil {
+		neat.ErrorLog(fmt.Sprintf("Failed to keep the champion as a ghost: %s", err))
+		return
+	}
+	e.champions = append(e.champions, ghostChampion{name: fmt.Sprintf("gen %d", epoch.Id), nf: nf})
+	if len(e.champions) > e.cfg.Ghosts {
+		e.champions = e.champions[len(e.champions)-e.cfg.Ghosts:]
+	}
+}
+
+// recordGhosts replays the kept champions headless on every course of the generation.
+func (e *flappyEvaluator) recordGhosts(seeds []int64) []game.Ghost {
+	windowW, windowH := e.gm.Size()
+	maxSteps := game.PipeSteps(windowW, e.cfg.SolvePipes)
+	var ghosts []game.Ghost
+	for _, champion := range e.champions {
+		net, err := champion.nf.Network()
+		if err != nil {
+			neat.ErrorLog(fmt.Sprintf("Failed to replay the %s champion: %s", champion.name, err))
+			continue
+		}
+		for _, seed := range seeds {
+			c := NewNetworkController(net, e.sensors)
+			ghosts = append(ghosts, game.RecordGhost(champion.name, c, windowW, windowH, seed, maxSteps))
+		}
+	}
+	return ghosts
+}
@@ -0,0 +1,38 @@
+package neapy
+
+import (
+	"github.com/yaricom/goNEAT/v4/neat"
+	"github.com/yaricom/goNEAT/v4/neat/genetics"
+	"github.com/yaricom/goNEAT/v4/neat/math"
+	"github.com/yaricom/goNEAT/v4/neat/network"
+)
+
+// newStarterGenome builds a genome like data/flappy_start.yaml: the bias and every input
+// linked with zero weight to every tanh output, no hidden nodes.
+func newStarterGenome(inputs, outputs int) *genetics.Genome {
+	traits := make([]*neat.Trait, 3)
+	for i := range traits {
+		traits[i] = neat.NewTrait()
+		traits[i].Id = i + 1
+		traits[i].Params[0] = float64(i+1) / 10
+	}
+
+	nodes := []*network.NNode{network.NewNNode(1, network.BiasNeuron)}
+	for i := 0; i < inputs; i++ {
+		nodes = append(nodes, network.NewNNode(len(nodes)+1, network.InputNeuron))
+	}
+	for _, n := range nodes {
+		n.ActivationType = math.NullActivation
+	}
+	sources := nodes
+	var genes []*genetics.Gene
+	for i := 0; i < outputs; i++ {
+		out := network.NewNNode(len(nodes)+1, network.OutputNeuron)
+		out.ActivationType = math.TanhActivation
+		nodes = append(nodes, out)
+		for _, in := range sources {
+			genes = append(genes, genetics.NewGeneWithTrait(traits[0], 0, in, out, false, int64(len(genes)+1), 0))
+		}
+	}
+	return genetics.NewGenome(1, traits, nodes, genes)
+}
@@ -0,0 +1,121 @@
+package neapy
+
+import (
+	"fmt"
+	"gographics/game"
+	"sync"
+
+	"github.com/yaricom/goNEAT/v4/neat"
+	"github.com/yaricom/goNEAT/v4/neat/network"
+)
+
+// NetworkController is a game controller driven by a NEAT phenotype.
+type NetworkController struct {
+	net     *network.Network
+	sensors *Sensors
+	err     error
+
+	// activations are kept only once somebody asked for them, training does not pay for it.
+	mu          sync.Mutex
+	watched     bool
+	activations map[int]float64
+}
+
+// NewNetworkController creates a controller feeding the network with the inputs of the sensor manifest.
+func NewNetworkController(net *network.Network, sensors *Sensors) *NetworkController {
+	return &NetworkController{net: net, sensors: sensors}
+}
+
+// Act jumps whenever the network output fires. Activation errors are logged and treated as no jump,
+// the first one is kept for Err.
+func (c *NetworkController) Act(obs game.Observation) bool {
+	c.mu.Lock()
+	var snapshot map[int]float64
+	if c.watched {
+		snapshot = make(map[int]float64, len(c.net.AllNodes()))
+	}
+	c.mu.Unlock()
+	out, err := activate(c.net, c.sensors.Read(obs), snapshot)
+	if snapshot != nil {
+		c.mu.Lock()
+		c.activations = snapshot
+		c.mu.Unlock()
+	}
+	if err != nil {
+		neat.ErrorLog(fmt.Sprintf("Network activation failed: %s", err))
+		if c.err == nil {
+			c.err = err
+		}
+		return false
+	}
+	return out > 0.5
+}
+
+// Err returns the first activation error, if any.
+func (c *NetworkController) Err() error {
+	return c.err
+}
+
+// Topology describes the network for the overlay, inputs are labelled by the sensor names.
+func (c *NetworkController) Topology() ([]game.BrainNode, []game.BrainLink) {
+	var (
+		nodes  []game.BrainNode
+		links  []game.BrainLink
+		inputs int
+	)
+	for _, n := range c.net.AllNodes() {
+		node := game.BrainNode{ID: n.Id}
+		switch n.NeuronType {
+		case network.InputNeuron:
+			node.Kind = game.BrainInput
+			if inputs < c.sensors.Len() {
+				node.Label = c.sensors.Names()[inputs]
+			}
+			inputs++
+		case network.BiasNeuron:
+			node.Kind, node.Label = game.BrainBias, "bias"
+		case network.OutputNeuron:
+			node.Kind, node.Label = game.BrainOutput, "jump"
+		default:
+			node.Kind = game.BrainHidden
+		}
+		nodes = append(nodes, node)
+		for _, l := range n.Incoming {
+			links = append(links, game.BrainLink{From: l.InNode.Id, To: n.Id, Weight: l.ConnectionWeight})
+		}
+	}
+	return nodes, links
+}
+
+// Activations returns the node activations of the last step.
+func (c *NetworkController) Activations() map[int]float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.watched = true
+	return c.activations
+}
+
+// activate feeds forward the inputs through the network and returns its single output.
+// The activation of every node is stored into snapshot unless it is nil.
+func activate(net *network.Network, inputs []float64, snapshot map[int]float64) (float64, error) {
+	depth, err := net.MaxActivationDepth()
+	if err != nil {
+		return 0, err
+	}
+	if err = net.LoadSensors(inputs); err != nil {
+		return 0, err
+	}
+	if _, err = net.ForwardSteps(depth); err != nil {
+		return 0, err
+	}
+	out := net.ReadOutputs()[0]
+	if snapshot != nil {
+		for _, n := range net.AllNodes() {
+			snapshot[n.Id] = n.Activation
+		}
+	}
+	if _, err = net.Flush(); err != nil {
+		return 0, err
+	}
+	return out, nil
+}
@@ -0,0 +1,148 @@
+package neapy
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/yaricom/goNEAT/v4/neat/genetics"
+)
+
+const (
+	SearchFitness = "fitness"
+	SearchNovelty = "novelty"
+)
+
+// NoveltyConfig holds the novelty search settings.
+type NoveltyConfig struct {
+	// K is the number of nearest neighbours novelty is averaged over.
+	K int `yaml:"k"`
+	// ArchiveSize is the maximal number of archived behaviours, the oldest ones are dropped first.
+	ArchiveSize int `yaml:"archive_size"`
+	// ArchiveAdd is the number of the most novel organisms archived every generation.
+	ArchiveAdd int `yaml:"archive_add"`
+	// Pipes is the number of pipes whose gap offsets characterize the behaviour on a course.
+	Pipes int `yaml:"pipes"`
+	// FitnessWeight blends the fitness into the novelty score: 0 is pure novelty, 1 is pure fitness.
+	FitnessWeight float64 `yaml:"fitness_weight"`
+}
+
+func (c NoveltyConfig) validate() error {
+	if c.K < 1 {
+		return fmt.Errorf("novelty k must be positive, got %d", c.K)
+	}
+	if c.ArchiveSize < 0 || c.ArchiveAdd < 0 {
+		return fmt.Errorf("novelty archive_size and archive_add must not be negative")
+	}
+	if c.Pipes < 0 {
+		return fmt.Errorf("novelty pipes must not be negative, got %d", c.Pipes)
+	}
+	if c.FitnessWeight < 0 || c.FitnessWeight > 1 {
+		return fmt.Errorf("novelty fitness_weight must be in [0, 1], got %f", c.FitnessWeight)
+	}
+	return nil
+}
+
+// behaviour characterizes what an organism did over the courses of a generation.
+type behaviour []float64
+
+// newBehaviour describes every course by the gap offsets at the first pipes, how many of them were passed
+// and where the gopher died.
+func newBehaviour(records []episodeRecord, pipes int) behaviour {
+	b := make(behaviour, 0, len(records)*(pipes+2))
+	for _, rec := range records {
+		for i := 0; i < pipes; i++ {
+			offset := 0.0
+			if i < len(rec.passOffsets) {
+				offset = rec.passOffsets[i]
+			}
+			b = append(b, offset)
+		}
+		progress := 1.0
+		if pipes > 0 {
+			progress = float64(min(rec.pipes, pipes)) / float64(pipes)
+		}
+		b = append(b, progress, rec.lastY)
+	}
+	return b
+}
+
+func (b behaviour) distance(other behaviour) float64 {
+	if len(b) != len(other) {
+		// behaviours of a different number of courses are as far as they can be
+		return math.Inf(1)
+	}
+	sum := 0.0
+	for i := range b {
+		d := b[i] - other[i]
+		sum += d * d
+	}
+	return math.Sqrt(sum)
+}
+
+// noveltySearch ranks organisms by how different their behaviour is from the population and the archive.
+// The archive lives as long as the evaluator and is saved in checkpoints.
+type noveltySearch struct {
+	cfg     NoveltyConfig
+	archive []behaviour
+}
+
+// score replaces the organisms fitness with their novelty, blended with the normalized objective fitness.
+// records holds the episodes of every organism in the order of the population.
+func (n *noveltySearch) score(pop *genetics.Population, records [][]episodeRecord) {
+	behaviours := make([]behaviour, len(records))
+	for i := range records {
+		behaviours[i] = newBehaviour(records[i], n.cfg.Pipes)
+	}
+	novelty := make([]float64, len(behaviours))
+	maxNovelty, maxFitness := 0.0, 0.0
+	for i, b := range behaviours {
+		novelty[i] = n.novelty(i, b, behaviours)
+		maxNovelty = math.Max(maxNovelty, novelty[i])
+		maxFitness = math.Max(maxFitness, objectiveFitness(pop.Organisms[i]))
+	}
+	for i, agent := range pop.Organisms {
+		s := 0.0
+		if maxNovelty > 0 {
+			s += (1 - n.cfg.FitnessWeight) * novelty[i] / maxNovelty
+		}
+		if maxFitness > 0 {
+			s += n.cfg.FitnessWeight * objectiveFitness(agent) / maxFitness
+		}
+		agent.Fitness = s
+	}
+	n.archiveMostNovel(behaviours, novelty)
+}
+
+// novelty is the mean distance from the behaviour to its k nearest neighbours in the population and the archive.
+func (n *noveltySearch) novelty(self int, b behaviour, population []behaviour) float64 {
+	distances := make([]float64, 0, len(population)+len(n.archive))
+	for i, other := range population {
+		if i != self {
+			distances = append(distances, b.distance(other))
+		}
+	}
+	for _, other := range n.archive {
+		distances = append(distances, b.distance(other))
+	}
+	sort.Float64s(distances)
+	k := min(n.cfg.K, len(distances))
+	if k == 0 {
+		return 0
+	}
+	return mean(distances[:k])
+}
+
+func (n *noveltySearch) archiveMostNovel(behaviours []behaviour, novelty []float64) {
+	order := make([]int, len(behaviours))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return novelty[order[a]] > novelty[order[b]] })
+	for _, i := range order[:min(n.cfg.ArchiveAdd, len(order))] {
+		n.archive = append(n.archive, behaviours[i])
+	}
+	if drop := len(n.archive) - n.cfg.ArchiveSize; drop > 0 {
+		n.archive = n.archive[drop:]
+	}
+}
@@ -0,0 +1,133 @@
+package neapy
+
+import (
+	"context"
+	"gographics/game"
+	"runtime"
+	"sync"
+
+	"github.com/yaricom/goNEAT/v4/experiment"
+	"github.com/yaricom/goNEAT/v4/neat"
+	"github.com/yaricom/goNEAT/v4/neat/genetics"
+)
+
+// parallelEvaluator plays every organism alone on headless games shared by a pool of workers.
+// Organisms do not affect each other, so the results do not depend on the number of workers.
+type parallelEvaluator struct {
+	windowW int
+	windowH int
+	workers int
+	cfg     *Config
+	scorer  *scorer
+	sensors *Sensors
+}
+
+// organismResult is the outcome of a single organism on every course of the generation.
+type organismResult struct {
+	records []episodeRecord
+	err     error
+}
+
+// NewParallelEvaluator creates an evaluator running headless games of the given size on cfg.Workers workers.
+func NewParallelEvaluator(windowW, windowH int, cfg *Config) (experiment.GenerationEvaluator, error) {
+	scorer, err := newScorer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	sensors, err := NewSensors(cfg.Sensors)
+	if err != nil {
+		return nil, err
+	}
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	return &parallelEvaluator{
+		windowW: windowW,
+		windowH: windowH,
+		workers: workers,
+		cfg:     cfg,
+		scorer:  scorer,
+		sensors: sensors,
+	}, nil
+}
+
+// GenerationStats returns the statistics of the last evaluated generation.
+func (e *parallelEvaluator) GenerationStats() GenerationStats {
+	return e.scorer.stats
+}
+
+func (e *parallelEvaluator) GenerationEvaluate(ctx context.Context, pop *genetics.Population, epoch *experiment.Generation) error {
+	defer e.scorer.fillStatistics(pop, epoch)
+	options, ok := neat.FromContext(ctx)
+	if !ok {
+		return neat.ErrNEATOptionsNotFound
+	}
+	seeds := courseSeeds(e.cfg)
+
+	results := make([]organismResult, len(pop.Organisms))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < e.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// the game is seeded right away, drawing a course from the global source would change the evolution
+			gm := game.NewSeededGame(e.windowW, e.windowH, 1, seeds[0])
+			for i := range jobs {
+				results[i] = e.evaluateOrganism(ctx, gm, pop.Organisms[i], seeds)
+			}
+		}()
+	}
+dispatch:
+	for i := range pop.Organisms {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	records := make([][]episodeRecord, len(pop.Organisms))
+	for i, res := range results {
+		if res.err != nil {
+			return res.err
+		}
+		records[i] = res.records
+	}
+	scoreGeneration(pop, seeds, records, e.scorer, epoch, options)
+	return nil
+}
+
+// evaluateOrganism plays the organism on every course using the worker game, it stops when ctx is done.
+func (e *parallelEvaluator) evaluateOrganism(ctx context.Context, gm *game.Game, agent *genetics.Organism,
+	seeds []int64) organismResult {
+	pheno, err := agent.Phenotype()
+	if err != nil {
+		return organismResult{err: err}
+	}
+	if err = e.sensors.CheckNetwork(pheno); err != nil {
+		return organismResult{err: err}
+	}
+	res := organismResult{}
+	c := NewNetworkController(pheno, e.sensors)
+	gm.SetController(0, c)
+	gm.SetPipeLimit(e.cfg.SolvePipes)
+	for _, seed := range seeds {
+		gm.SetSeed(seed)
+		gm.Restart(1)
+		if err = gm.RunContext(ctx, 0); err != nil {
+			return organismResult{err: err}
+		}
+		if err = c.Err(); err != nil {
+			return organismResult{err: err}
+		}
+		res.records = append(res.records, newEpisodeRecord(gm.Records()[0]))
+	}
+	return res
+}
@@ -0,0 +1,107 @@
+package neapy
+
+import (
+	"context"
+	"fmt"
+	"gographics/game"
+	"math/rand"
+	"runtime"
+	"testing"
+
+	"github.com/yaricom/goNEAT/v4/experiment"
+	"github.com/yaricom/goNEAT/v4/neat"
+	"github.com/yaricom/goNEAT/v4/neat/genetics"
+)
+
+// newTestPopulation spawns a population from the starter genome of cfg with the global random source seeded.
+func newTestPopulation(tb testing.TB, cfg *Config) (*neat.Options, *genetics.Population) {
+	tb.Helper()
+	opts, err := neat.ReadNeatOptionsFromFile("../data/flappy.neat.yaml")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	neat.LogLevel = neat.LogLevelWarning
+	sensors, err := NewSensors(cfg.Sensors)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	rand.Seed(1)
+	pop, err := genetics.NewPopulation(sensors.StarterGenome(), opts)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return opts, pop
+}
+
+// newTestConfig evaluates on a few fixed courses with the given number of workers.
+func newTestConfig(workers int) *Config {
+	cfg := DefaultConfig()
+	cfg.Workers = workers
+	cfg.Episodes = 3
+	cfg.Seed = 42
+	cfg.SolvePipes = 5
+	return cfg
+}
+
+// evaluateWithWorkers evaluates a freshly spawned population on the given number of workers and returns
+// the organisms fitness and the next draw of the global random source, which goNEAT reproduces from.
+func evaluateWithWorkers(t *testing.T, workers int) ([]float64, int64) {
+	t.Helper()
+	cfg := newTestConfig(workers)
+	opts, pop := newTestPopulation(t, cfg)
+	eval, err := NewParallelEvaluator(game.WorldWidth, game.WorldHeight, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = eval.GenerationEvaluate(neat.NewContext(context.Background(), opts), pop, &experiment.Generation{}); err != nil {
+		t.Fatal(err)
+	}
+	fitness := make([]float64, len(pop.Organisms))
+	for i, agent := range pop.Organisms {
+		fitness[i] = agent.Fitness
+	}
+	return fitness, rand.Int63()
+}
+
+func TestParallelEvaluatorWorkers(t *testing.T) {
+	fitness1, next1 := evaluateWithWorkers(t, 1)
+	fitnessN, nextN := evaluateWithWorkers(t, 4)
+	if len(fitness1) != len(fitnessN) {
+		t.Fatalf("population sizes differ: %d and %d", len(fitness1), len(fitnessN))
+	}
+	for i := range fitness1 {
+		if fitness1[i] != fitnessN[i] {
+			t.Errorf("organism %d: fitness %f on 1 worker, %f on 4 workers", i, fitness1[i], fitnessN[i])
+		}
+	}
+	if next1 != nextN {
+		t.Errorf("the global random source was drawn from differently on 1 and 4 workers")
+	}
+}
+
+// BenchmarkParallelEvaluator evaluates a generation on a growing number of workers, the time per generation
+// should drop close to linearly up to the number of cores.
+func BenchmarkParallelEvaluator(b *testing.B) {
+	workers := []int{1, 2, 4, 8}
+	if n := runtime.NumCPU(); n > 8 {
+		workers = append(workers, n)
+	}
+	for _, n := range workers {
+		b.Run(fmt.Sprintf("workers=%d", n), func(b *testing.B) {
+			cfg := newTestConfig(n)
+			opts, pop := newTestPopulation(b, cfg)
+			eval, err := NewParallelEvaluator(game.WorldWidth, game.WorldHeight, cfg)
+			if err != nil {
+				b.Fatal(err)
+			}
+			ctx := neat.NewContext(context.Background(), opts)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err = eval.GenerationEvaluate(ctx, pop, &experiment.Generation{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,276 @@
+package neapy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/yaricom/goNEAT/v4/experiment"
+	"github.com/yaricom/goNEAT/v4/neat"
+	"github.com/yaricom/goNEAT/v4/neat/genetics"
+)
+
+const SearchPareto = "pareto"
+
+// The objectives of the multi-objective search.
+const (
+	ObjectiveSurvival       = "survival"
+	ObjectiveSize           = "size"
+	ObjectiveJumpEfficiency = "jump_efficiency"
+)
+
+var objectiveNames = []string{ObjectiveSurvival, ObjectiveSize, ObjectiveJumpEfficiency}
+
+// ParetoConfig holds the multi-objective search settings.
+type ParetoConfig struct {
+	// Preference weighs the objectives to pick the champion from the first front.
+	// Objectives are normalized within the front, missing ones weigh 0.
+	Preference map[string]float64 `yaml:"preference"`
+}
+
+func (c ParetoConfig) validate() error {
+	total := 0.0
+	for name, w := range c.Preference {
+		known := false
+		for _, o := range objectiveNames {
+			known = known || o == name
+		}
+		if !known {
+			return fmt.Errorf("unknown objective '%s' in pareto preference, expected one of: %v", name, objectiveNames)
+		}
+		if w < 0 {
+			return fmt.Errorf("pareto preference of '%s' must not be negative, got %f", name, w)
+		}
+		total += w
+	}
+	if total == 0 {
+		return fmt.Errorf("pareto preference must weigh at least one objective")
+	}
+	return nil
+}
+
+// paretoMember is an organism with its objectives, all of them maximized.
+type paretoMember struct {
+	org *genetics.Organism
+	// survival is the aggregated number of steps survived over the courses.
+	survival float64
+	// size is the number of nodes and enabled genes, negated to be maximized.
+	size float64
+	// jumpEfficiency is the number of pipes passed per jump.
+	jumpEfficiency float64
+
+	rank     int
+	crowding float64
+}
+
+func (m *paretoMember) objectives() []float64 {
+	return []float64{m.survival, m.size, m.jumpEfficiency}
+}
+
+// dominates reports whether m is not worse than other in any objective and better in one.
+func (m *paretoMember) dominates(other *paretoMember) bool {
+	better := false
+	theirs := other.objectives()
+	for i, v := range m.objectives() {
+		if v < theirs[i] {
+			return false
+		}
+		better = better || v > theirs[i]
+	}
+	return better
+}
+
+// paretoSearch ranks organisms NSGA-II style: by Pareto front first, by crowding distance within the front.
+type paretoSearch struct {
+	cfg ParetoConfig
+	// dir is where the fronts of every generation are written, nothing is written if empty.
+	dir string
+	// champion is the preferred organism of the first front of the last generation.
+	champion *genetics.Organism
+}
+
+func newParetoMember(org *genetics.Organism, records []episodeRecord, aggregate Aggregate) *paretoMember {
+	steps := make([]float64, len(records))
+	pipes, jumps := 0, 0
+	for i, rec := range records {
+		steps[i] = float64(rec.steps)
+		pipes += rec.pipes
+		jumps += rec.jumps
+	}
+	return &paretoMember{
+		org:            org,
+		survival:       aggregate(steps),
+		size:           -float64(len(org.Genotype.Nodes) + org.Genotype.Extrons()),
+		jumpEfficiency: float64(pipes) / float64(max(jumps, 1)),
+	}
+}
+
+// score replaces the organisms fitness with their Pareto rank and crowding: every front scores above
+// the next one, and less crowded organisms score higher within a front.
+// records holds the episodes of every organism in the order of the population.
+// The fronts are written into dir, failing to write them does not stop the evolution.
+func (p *paretoSearch) score(pop *genetics.Population, records [][]episodeRecord, aggregate Aggregate,
+	epoch *experiment.Generation) {
+	members := make([]*paretoMember, len(pop.Organisms))
+	for i, agent := range pop.Organisms {
+		members[i] = newParetoMember(agent, records[i], aggregate)
+	}
+	fronts := nonDominatedSort(members)
+	for _, front := range fronts {
+		crowdingDistance(front)
+		for _, m := range front {
+			crowding := 1.0
+			if !math.IsInf(m.crowding, 1) {
+				crowding = m.crowding / (1 + m.crowding)
+			}
+			m.org.Fitness = float64(len(fronts)-m.rank) + 0.5*crowding
+		}
+	}
+	p.champion = p.preferred(fronts[0])
+	if p.dir == "" {
+		return
+	}
+	path := filepath.Join(p.dir, runGenName(epoch.TrialId, epoch.Id)+".json")
+	if err := p.writeFronts(path, fronts); err != nil {
+		neat.ErrorLog(fmt.Sprintf("Failed to write Pareto fronts: %s", err))
+	}
+}
+
+// preferred picks the member of the front with the best weighted sum of normalized objectives.
+func (p *paretoSearch) preferred(front []*paretoMember) *genetics.Organism {
+	weights := []float64{
+		p.cfg.Preference[ObjectiveSurvival],
+		p.cfg.Preference[ObjectiveSize],
+		p.cfg.Preference[ObjectiveJumpEfficiency],
+	}
+	lo, hi := objectiveBounds(front)
+	var best *paretoMember
+	bestScore := math.Inf(-1)
+	for _, m := range front {
+		score := 0.0
+		for i, v := range m.objectives() {
+			if hi[i] > lo[i] {
+				score += weights[i] * (v - lo[i]) / (hi[i] - lo[i])
+			}
+		}
+		if score > bestScore {
+			best, bestScore = m, score
+		}
+	}
+	return best.org
+}
+
+// nonDominatedSort splits the members into fronts: the first one is dominated by nobody,
+// every next one only by the members of the previous fronts.
+func nonDominatedSort(members []*paretoMember) [][]*paretoMember {
+	dominated := make([][]int, len(members))
+	dominatedBy := make([]int, len(members))
+	var current []int
+	for i, m := range members {
+		for j, other := range members {
+			switch {
+			case m.dominates(other):
+				dominated[i] = append(dominated[i], j)
+			case other.dominates(m):
+				dominatedBy[i]++
+			}
+		}
+		if dominatedBy[i] == 0 {
+			current = append(current, i)
+		}
+	}
+	var fronts [][]*paretoMember
+	for rank := 0; len(current) > 0; rank++ {
+		front := make([]*paretoMember, len(current))
+		var next []int
+		for k, i := range current {
+			members[i].rank = rank
+			front[k] = members[i]
+			for _, j := range dominated[i] {
+				if dominatedBy[j]--; dominatedBy[j] == 0 {
+					next = append(next, j)
+				}
+			}
+		}
+		fronts = append(fronts, front)
+		current = next
+	}
+	return fronts
+}
+
+// crowdingDistance sets how far every member of the front is from its neighbours over all objectives.
+// The members at the ends of any objective are infinitely far.
+func crowdingDistance(front []*paretoMember) {
+	for _, m := range front {
+		m.crowding = 0
+	}
+	lo, hi := objectiveBounds(front)
+	sorted := append([]*paretoMember(nil), front...)
+	for i := range objectiveNames {
+		sort.SliceStable(sorted, func(a, b int) bool { return sorted[a].objectives()[i] < sorted[b].objectives()[i] })
+		sorted[0].crowding = math.Inf(1)
+		sorted[len(sorted)-1].crowding = math.Inf(1)
+		if hi[i] == lo[i] {
+			continue
+		}
+		for k := 1; k < len(sorted)-1; k++ {
+			gap := sorted[k+1].objectives()[i] - sorted[k-1].objectives()[i]
+			sorted[k].crowding += gap / (hi[i] - lo[i])
+		}
+	}
+}
+
+// objectiveBounds returns the lowest and the highest value of every objective in the front.
+func objectiveBounds(front []*paretoMember) (lo, hi []float64) {
+	for _, m := range front {
+		obj := m.objectives()
+		if lo == nil {
+			lo, hi = append([]float64(nil), obj...), append([]float64(nil), obj...)
+			continue
+		}
+		for i, v := range obj {
+			lo[i], hi[i] = math.Min(lo[i], v), math.Max(hi[i], v)
+		}
+	}
+	return lo, hi
+}
+
+// ParetoFront is the exported form of a Pareto front.
+type ParetoFront struct {
+	Rank    int                 `json:"rank"`
+	Members []ParetoFrontMember `json:"members"`
+}
+
+type ParetoFrontMember struct {
+	GenomeId       int     `json:"genome_id"`
+	Survival       float64 `json:"survival"`
+	Size           int     `json:"size"`
+	JumpEfficiency float64 `json:"jump_efficiency"`
+}
+
+func (p *paretoSearch) writeFronts(path string, fronts [][]*paretoMember) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	out := make([]ParetoFront, len(fronts))
+	for rank, front := range fronts {
+		out[rank].Rank = rank
+		for _, m := range front {
+			out[rank].Members = append(out[rank].Members, ParetoFrontMember{
+				GenomeId:       m.org.Genotype.Id,
+				Survival:       m.survival,
+				Size:           int(-m.size),
+				JumpEfficiency: m.jumpEfficiency,
+			})
+		}
+	}
+	return writeFile(path, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	})
+}
@@ -0,0 +1,141 @@
+package neapy
+
+import (
+	"fmt"
+	"gographics/game"
+	"sort"
+	"strings"
+
+	"github.com/yaricom/goNEAT/v4/neat/genetics"
+	"github.com/yaricom/goNEAT/v4/neat/network"
+)
+
+// The sensors known to the registry.
+const (
+	SensorPosY      = "pos_y"
+	SensorSpeedY    = "speed_y"
+	SensorPipeBotY  = "pipe_bot_y"
+	SensorPipeTopY  = "pipe_top_y"
+	SensorGapOffset = "gap_offset"
+	SensorPipeDistX = "pipe_dist_x"
+)
+
+// DefaultSensors is the sensor manifest of data/flappy_start.yaml.
+var DefaultSensors = []string{SensorPosY, SensorSpeedY, SensorPipeBotY, SensorPipeTopY}
+
+// networkOutputs is the number of outputs of a flappy network: whether to jump.
+const networkOutputs = 1
+
+var sensorRegistry = map[string]func(obs game.Observation) float64{
+	// top of the gopher as a fraction of the window height
+	SensorPosY: func(obs game.Observation) float64 { return obs.Gopher.PosYpercent },
+	// vertical speed of the gopher, positive when falling
+	SensorSpeedY: func(obs game.Observation) float64 { return obs.Gopher.SpeedY },
+	// bottom of the upper pipe of the closest pipe ahead
+	SensorPipeBotY: func(obs game.Observation) float64 { return obs.PipeBotY },
+	// top of the lower pipe of the closest pipe ahead
+	SensorPipeTopY: func(obs game.Observation) float64 { return obs.PipeTopY },
+	// vertical distance from the gopher to the centre of the gap ahead
+	SensorGapOffset: func(obs game.Observation) float64 {
+		return (obs.PipeBotY+obs.PipeTopY)/2 - obs.Gopher.PosYpercent
+	},
+	// horizontal distance to the tail of the closest pipe ahead as a fraction of the window width
+	SensorPipeDistX: func(obs game.Observation) float64 { return obs.PipeDistX },
+}
+
+// SensorNames returns the names of the registered sensors.
+func SensorNames() []string {
+	names := make([]string, 0, len(sensorRegistry))
+	for name := range sensorRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Sensors is a sensor manifest: the named network inputs in the order of the genome input nodes.
+type Sensors struct {
+	names []string
+	read  []func(obs game.Observation) float64
+}
+
+// NewSensors builds the manifest of the named sensors.
+func NewSensors(names []string) (*Sensors, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("sensor manifest is empty")
+	}
+	s := &Sensors{names: names}
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		read, ok := sensorRegistry[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown sensor '%s', expected one of: %s", name, strings.Join(SensorNames(), ", "))
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("sensor '%s' is listed twice", name)
+		}
+		seen[name] = true
+		s.read = append(s.read, read)
+	}
+	return s, nil
+}
+
+// Names returns the sensor names in the input order.
+func (s *Sensors) Names() []string {
+	return s.names
+}
+
+// Len returns the number of network inputs.
+func (s *Sensors) Len() int {
+	return len(s.names)
+}
+
+// Read returns the network inputs for the observation.
+func (s *Sensors) Read(obs game.Observation) []float64 {
+	inputs := make([]float64, len(s.read))
+	for i, read := range s.read {
+		inputs[i] = read(obs)
+	}
+	return inputs
+}
+
+// CheckGenome verifies the genome has an input node per sensor and a single output.
+func (s *Sensors) CheckGenome(genome *genetics.Genome) error {
+	inputs, outputs := 0, 0
+	for _, n := range genome.Nodes {
+		switch n.NeuronType {
+		case network.InputNeuron:
+			inputs++
+		case network.OutputNeuron:
+			outputs++
+		}
+	}
+	return s.check(fmt.Sprintf("genome %d", genome.Id), inputs, outputs)
+}
+
+// CheckNetwork verifies the network has an input node per sensor and a single output.
+func (s *Sensors) CheckNetwork(net *network.Network) error {
+	inputs := 0
+	for _, n := range net.AllNodes() {
+		if n.NeuronType == network.InputNeuron {
+			inputs++
+		}
+	}
+	return s.check(fmt.Sprintf("network %d", net.Id), inputs, len(net.Outputs))
+}
+
+func (s *Sensors) check(what string, inputs, outputs int) error {
+	if inputs != s.Len() {
+		return fmt.Errorf("%s has %d input nodes, the sensor manifest [%s] needs %d",
+			what, inputs, strings.Join(s.names, ", "), s.Len())
+	}
+	if outputs != networkOutputs {
+		return fmt.Errorf("%s has %d output nodes, expected %d", what, outputs, networkOutputs)
+	}
+	return nil
+}
+
+// StarterGenome generates the start genome for the manifest: every sensor and the bias linked to the output.
+func (s *Sensors) StarterGenome() *genetics.Genome {
+	return newStarterGenome(s.Len(), networkOutputs)
+}
@@ -0,0 +1,188 @@
+package neapy
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/yaricom/goNEAT/v4/experiment"
+	"github.com/yaricom/goNEAT/v4/neat"
+	"github.com/yaricom/goNEAT/v4/neat/genetics"
+)
+
+// GenerationStats summarizes an evaluated generation. Fitness is the objective fitness aggregated
+// over the courses, whatever organisms were selected for.
+type GenerationStats struct {
+	Run           int
+	Generation    int
+	BestFitness   float64
+	MeanFitness   float64
+	MedianFitness float64
+	// Species is the number of species in the population.
+	Species int
+	// SpeciesSizes is the number of organisms of every species by species id.
+	SpeciesSizes map[int]int
+	// Complexity is the number of nodes and genes of the champion.
+	Complexity int
+	// BestPipes is the most pipes passed on a single course, MeanPipes is the mean over all episodes.
+	BestPipes int
+	MeanPipes float64
+	// Duration is the wall time of the generation, evaluation and reproduction.
+	Duration time.Duration
+	// Episodes is the outcome of the population on every course in the order they were played.
+	Episodes []EpisodeStats
+}
+
+// StatsSource is an evaluator reporting the statistics of the last evaluated generation.
+type StatsSource interface {
+	GenerationStats() GenerationStats
+}
+
+// newGenerationStats collects the fitness and pipe statistics of the evaluated population.
+func newGenerationStats(pop *genetics.Population, records [][]episodeRecord) GenerationStats {
+	fitness := make([]float64, len(pop.Organisms))
+	for i, agent := range pop.Organisms {
+		fitness[i] = objectiveFitness(agent)
+	}
+	sorted := sortedCopy(fitness)
+	stats := GenerationStats{
+		MeanFitness:   mean(fitness),
+		MedianFitness: median(sorted),
+		SpeciesSizes:  make(map[int]int, len(pop.Species)),
+	}
+	for _, sp := range pop.Species {
+		stats.SpeciesSizes[sp.Id] = len(sp.Organisms)
+	}
+	if len(sorted) > 0 {
+		stats.BestFitness = sorted[len(sorted)-1]
+	}
+	pipes, episodes := 0, 0
+	for _, episode := range records {
+		for _, rec := range episode {
+			stats.BestPipes = max(stats.BestPipes, rec.pipes)
+			pipes += rec.pipes
+			episodes++
+		}
+	}
+	if episodes > 0 {
+		stats.MeanPipes = float64(pipes) / float64(episodes)
+	}
+	return stats
+}
+
+// Complete adds to the evaluator statistics what is known once the generation is over.
+func (stats GenerationStats) Complete(trial *experiment.Trial, epoch *experiment.Generation) GenerationStats {
+	stats.Run = trial.Id
+	stats.Generation = epoch.Id
+	stats.Species = epoch.Diversity
+	if epoch.Champion != nil {
+		stats.Complexity = epoch.ChampionComplexity()
+	}
+	stats.Duration = epoch.Duration
+	return stats
+}
+
+var statsHeader = []string{
+	"run", "generation", "best_fitness", "mean_fitness", "median_fitness",
+	"species", "complexity", "best_pipes", "mean_pipes", "wall_time_s",
+}
+
+// StatsRecorder is a trial observer writing a CSV row of statistics for every generation.
+type StatsRecorder struct {
+	source  StatsSource
+	courses int
+	file    *os.File
+	w       *csv.Writer
+}
+
+// NewStatsRecorder creates the CSV file at path, the statistics come from the evaluator. Every row ends with
+// the seed, best and mean pipes of each of the given number of courses.
+func NewStatsRecorder(path string, source StatsSource, courses int) (*StatsRecorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &StatsRecorder{source: source, courses: courses, file: f, w: csv.NewWriter(f)}
+	header := append([]string(nil), statsHeader...)
+	for i := 1; i <= courses; i++ {
+		header = append(header, fmt.Sprintf("course%d_seed", i), fmt.Sprintf("course%d_best_pipes", i),
+			fmt.Sprintf("course%d_mean_pipes", i))
+	}
+	if err = r.write(header); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *StatsRecorder) TrialRunStarted(*experiment.Trial) {}
+
+func (r *StatsRecorder) TrialRunFinished(*experiment.Trial) {}
+
+func (r *StatsRecorder) EpochEvaluated(trial *experiment.Trial, epoch *experiment.Generation) {
+	stats := r.source.GenerationStats().Complete(trial, epoch)
+	row := []string{
+		strconv.Itoa(stats.Run),
+		strconv.Itoa(stats.Generation),
+		formatFloat(stats.BestFitness),
+		formatFloat(stats.MeanFitness),
+		formatFloat(stats.MedianFitness),
+		strconv.Itoa(stats.Species),
+		strconv.Itoa(stats.Complexity),
+		strconv.Itoa(stats.BestPipes),
+		formatFloat(stats.MeanPipes),
+		formatFloat(stats.Duration.Seconds()),
+	}
+	for i := 0; i < r.courses; i++ {
+		if i >= len(stats.Episodes) {
+			row = append(row, "", "", "")
+			continue
+		}
+		episode := stats.Episodes[i]
+		row = append(row, strconv.FormatInt(episode.Seed, 10), strconv.Itoa(episode.BestPipes), formatFloat(episode.MeanPipes))
+	}
+	if err := r.write(row); err != nil {
+		neat.ErrorLog(fmt.Sprintf("Failed to write generation statistics: %s", err))
+	}
+}
+
+// Close closes the CSV file.
+func (r *StatsRecorder) Close() error {
+	return r.file.Close()
+}
+
+// write writes the row and flushes it, so the file is up-to-date while training.
+func (r *StatsRecorder) write(row []string) error {
+	if err := r.w.Write(row); err != nil {
+		return err
+	}
+	r.w.Flush()
+	return r.w.Error()
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// Observers notifies every observer in order.
+type Observers []experiment.TrialRunObserver
+
+func (o Observers) TrialRunStarted(trial *experiment.Trial) {
+	for _, obs := range o {
+		obs.TrialRunStarted(trial)
+	}
+}
+
+func (o Observers) TrialRunFinished(trial *experiment.Trial) {
+	for _, obs := range o {
+		obs.TrialRunFinished(trial)
+	}
+}
+
+func (o Observers) EpochEvaluated(trial *experiment.Trial, epoch *experiment.Generation) {
+	for _, obs := range o {
+		obs.EpochEvaluated(trial, epoch)
+	}
+}
@@ -0,0 +1,160 @@
+package neapy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/yaricom/goNEAT/v4/experiment"
+	"github.com/yaricom/goNEAT/v4/neat"
+	"github.com/yaricom/goNEAT/v4/neat/genetics"
+)
+
+// Trainer runs the experiment generation by generation like experiment.Experiment.Execute does,
+// checkpointing the evolution state so an interrupted run can be resumed.
+type Trainer struct {
+	Experiment *experiment.Experiment
+	Evaluator  experiment.GenerationEvaluator
+	// Observer is notified about trials and generations, optional.
+	Observer experiment.TrialRunObserver
+	// CheckpointDir is where checkpoints are written. Checkpointing is disabled if empty.
+	CheckpointDir string
+	// CheckpointEvery is the number of generations between checkpoints.
+	CheckpointEvery int
+}
+
+// Execute runs the experiment from the start genome or, if resume is set, from the latest checkpoint.
+// goNEAT draws from the global random source, so it is reseeded from the experiment seed at every generation:
+// a resumed run continues exactly like the uninterrupted one would.
+func (t *Trainer) Execute(ctx context.Context, startGenome *genetics.Genome, resume bool) error {
+	opts, found := neat.FromContext(ctx)
+	if !found {
+		return neat.ErrNEATOptionsNotFound
+	}
+	if t.Experiment.Trials == nil {
+		t.Experiment.Trials = make(experiment.Trials, opts.NumRuns)
+	}
+
+	var (
+		pop      *genetics.Population
+		startRun int
+		startGen int
+		elapsed  time.Duration
+	)
+	if resume {
+		cp, cpPop, err := readCheckpoint(t.CheckpointDir, opts, t.Experiment)
+		switch {
+		case errors.Is(err, ErrNoCheckpoint):
+			neat.WarnLog(fmt.Sprintf("No checkpoint in '%s', starting from scratch", t.CheckpointDir))
+		case err != nil:
+			return err
+		default:
+			pop, startRun, startGen, elapsed = cpPop, cp.Run, cp.Generation, cp.Elapsed
+			if state, ok := t.Evaluator.(evaluatorState); ok {
+				state.restoreState(cp)
+			}
+		}
+	}
+
+	for run := startRun; run < opts.NumRuns; run++ {
+		trialStartTime := time.Now().Add(-elapsed)
+		if pop == nil {
+			rand.Seed(generationSeed(t.Experiment.RandSeed, run, -1))
+			neat.InfoLog("\n>>>>> Spawning new population ")
+			var err error
+			if pop, err = genetics.NewPopulation(startGenome, opts); err != nil {
+				return err
+			}
+			if _, err = pop.Verify(); err != nil {
+				return err
+			}
+		}
+		epochExecutor, err := epochExecutorFor(opts)
+		if err != nil {
+			return err
+		}
+
+		trial := t.Experiment.Trials[run]
+		trial.Id = run
+		if t.Observer != nil && startGen == 0 {
+			t.Observer.TrialRunStarted(&trial)
+		}
+		for generationId := startGen; generationId < opts.NumGenerations; generationId++ {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+			}
+			rand.Seed(generationSeed(t.Experiment.RandSeed, run, generationId))
+
+			neat.InfoLog(fmt.Sprintf(">>>>> Generation:%3d\tRun: %d\n", generationId, run))
+			generation := experiment.Generation{
+				Id:      generationId,
+				TrialId: run,
+			}
+			genStartTime := time.Now()
+			if err = t.Evaluator.GenerationEvaluate(ctx, pop, &generation); err != nil {
+				return fmt.Errorf("generation [%d] evaluation failed: %w", generationId, err)
+			}
+			if !generation.Solved {
+				if err = epochExecutor.NextEpoch(ctx, generationId, pop); err != nil {
+					return fmt.Errorf("epoch execution failed in generation [%d]: %w", generationId, err)
+				}
+			}
+			// taken after reproduction, so the generation duration covers it
+			generation.Executed = time.Now()
+			generation.Duration = generation.Executed.Sub(genStartTime)
+			trial.Generations = append(trial.Generations, generation)
+			if t.Observer != nil {
+				t.Observer.EpochEvaluated(&trial, &generation)
+			}
+
+			if generation.Solved {
+				neat.InfoLog(fmt.Sprintf(">>>>> The winner organism found in [%d] generation, fitness: %f <<<<<\n",
+					generationId, generation.Champion.Fitness))
+				break
+			}
+			if t.CheckpointDir != "" && t.CheckpointEvery > 0 && (generationId+1)%t.CheckpointEvery == 0 {
+				t.Experiment.Trials[run] = trial
+				cp := &Checkpoint{
+					Run:        run,
+					Generation: generationId + 1,
+					Seed:       t.Experiment.RandSeed,
+					Elapsed:    time.Since(trialStartTime),
+				}
+				if state, ok := t.Evaluator.(evaluatorState); ok {
+					state.saveState(cp)
+				}
+				if err = writeCheckpoint(t.CheckpointDir, cp, pop, t.Experiment); err != nil {
+					return fmt.Errorf("failed to write checkpoint: %w", err)
+				}
+			}
+		}
+		trial.Duration = time.Since(trialStartTime)
+		t.Experiment.Trials[run] = trial
+		if t.Observer != nil {
+			t.Observer.TrialRunFinished(&trial)
+		}
+
+		pop, startGen, elapsed = nil, 0, 0
+	}
+	return nil
+}
+
+// generationSeed derives the random seed of the generation in the run, generation -1 spawns the population.
+func generationSeed(seed int64, run, generation int) int64 {
+	return seed + int64(run)*1_000_003 + int64(generation) + 1
+}
+
+func epochExecutorFor(opts *neat.Options) (genetics.PopulationEpochExecutor, error) {
+	switch opts.EpochExecutorType {
+	case neat.EpochExecutorTypeSequential:
+		return &genetics.SequentialPopulationEpochExecutor{}, nil
+	case neat.EpochExecutorTypeParallel:
+		return &genetics.ParallelPopulationEpochExecutor{}, nil
+	default:
+		return nil, errors.New("unsupported epoch executor type requested")
+	}
+}
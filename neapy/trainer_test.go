@@ -0,0 +1,92 @@
+package neapy
+
+import (
+	"context"
+	"fmt"
+	"gographics/game"
+	"testing"
+
+	"github.com/yaricom/goNEAT/v4/experiment"
+	"github.com/yaricom/goNEAT/v4/neat"
+)
+
+// generationSummary is what a generation ended with.
+type generationSummary struct {
+	champion  int
+	fitness   float64
+	diversity int
+	species   string
+}
+
+// summaryObserver collects the summaries of the evaluated generations by generation id.
+type summaryObserver map[int]generationSummary
+
+func (o summaryObserver) TrialRunStarted(*experiment.Trial) {}
+
+func (o summaryObserver) TrialRunFinished(*experiment.Trial) {}
+
+func (o summaryObserver) EpochEvaluated(_ *experiment.Trial, epoch *experiment.Generation) {
+	o[epoch.Id] = generationSummary{
+		champion:  epoch.Champion.Genotype.Id,
+		fitness:   epoch.Champion.Fitness,
+		diversity: epoch.Diversity,
+		species:   fmt.Sprint(epoch.Fitness),
+	}
+}
+
+// train runs the given number of generations headless, checkpointing into dir after every one of them.
+func train(t *testing.T, dir string, generations int, resume bool, obs summaryObserver) {
+	t.Helper()
+	opts, err := neat.ReadNeatOptionsFromFile("../data/flappy.neat.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	neat.LogLevel = neat.LogLevelWarning
+	opts.NumRuns = 1
+	opts.NumGenerations = generations
+	opts.EpochExecutorType = neat.EpochExecutorTypeSequential
+	cfg := DefaultConfig()
+	cfg.Workers = 2
+	cfg.Episodes = 2
+	cfg.Seed = 42
+	sensors, err := NewSensors(cfg.Sensors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eval, err := NewParallelEvaluator(game.WorldWidth, game.WorldHeight, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trainer := &Trainer{
+		Experiment:      &experiment.Experiment{RandSeed: 123},
+		Evaluator:       eval,
+		Observer:        obs,
+		CheckpointDir:   dir,
+		CheckpointEvery: 1,
+	}
+	if err = trainer.Execute(neat.NewContext(context.Background(), opts), sensors.StarterGenome(), resume); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestTrainerResume checks a run interrupted and resumed from its checkpoint ends like the uninterrupted one.
+func TestTrainerResume(t *testing.T) {
+	const before, after = 3, 3
+	uninterrupted := summaryObserver{}
+	train(t, t.TempDir(), before+after, false, uninterrupted)
+
+	resumed := summaryObserver{}
+	dir := t.TempDir()
+	train(t, dir, before, false, resumed)
+	train(t, dir, before+after, true, resumed)
+
+	for gen := 0; gen < before+after; gen++ {
+		want, ok := uninterrupted[gen]
+		if !ok {
+			t.Fatalf("generation %d was not evaluated in the uninterrupted run", gen)
+		}
+		if got := resumed[gen]; got != want {
+			t.Errorf("generation %d: resumed run ended with %+v, uninterrupted one with %+v", gen, got, want)
+		}
+	}
+}